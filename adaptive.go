@@ -0,0 +1,159 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// lowRemainingFraction is the share of a rate limit window left at which the
+// concurrency limit starts backing off
+const lowRemainingFraction = 0.1
+
+// AdaptiveConcurrency bounds the number of concurrent requests and tunes the
+// bound from the x-ratelimit-remaining-* headers and 429 responses: the limit
+// grows by about one per round of successful requests, shrinks gently when a
+// rate limit window is nearly used up, and halves on a 429. It suits bulk
+// embedding or chat jobs fanned out over many goroutines.
+type AdaptiveConcurrency struct {
+	// Min and Max bound the limit. Min defaults to 1 and Max to 64.
+	Min, Max int
+	// Initial is the starting limit; it defaults to Min.
+	Initial int
+}
+
+// WithAdaptiveConcurrency limits concurrent requests with a limit that adapts
+// to the server's rate limit feedback.
+func WithAdaptiveConcurrency(cfg AdaptiveConcurrency) ClientOption {
+	return func(c *Client) {
+		c.concurrency = newConcurrencyController(cfg)
+	}
+}
+
+// ConcurrencyLimit returns the current adaptive concurrency limit, or zero
+// when WithAdaptiveConcurrency is not used
+func (c *Client) ConcurrencyLimit() int {
+	if c.concurrency == nil {
+		return 0
+	}
+	c.concurrency.mu.Lock()
+	defer c.concurrency.mu.Unlock()
+	return c.concurrency.current()
+}
+
+// concurrencyController is a semaphore whose size follows an AIMD policy
+type concurrencyController struct {
+	min, max float64
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	waiters  []chan struct{}
+}
+
+// newConcurrencyController applies the defaults of cfg
+func newConcurrencyController(cfg AdaptiveConcurrency) *concurrencyController {
+	lo, hi := max(cfg.Min, 1), cfg.Max
+	if hi <= 0 {
+		hi = 64
+	}
+	hi = max(hi, lo)
+	initial := cfg.Initial
+	if initial <= 0 {
+		initial = lo
+	}
+	return &concurrencyController{
+		min:   float64(lo),
+		max:   float64(hi),
+		limit: float64(min(max(initial, lo), hi)),
+	}
+}
+
+// current returns the whole number of slots. It must be called with mu held.
+func (cc *concurrencyController) current() int {
+	return int(cc.limit)
+}
+
+// acquire takes a slot, waiting until one is free or ctx is done
+func (cc *concurrencyController) acquire(ctx context.Context) error {
+	cc.mu.Lock()
+	if cc.inflight < cc.current() {
+		cc.inflight++
+		cc.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	cc.waiters = append(cc.waiters, ready)
+	cc.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		cc.mu.Lock()
+		defer cc.mu.Unlock()
+		for i, w := range cc.waiters {
+			if w == ready {
+				cc.waiters = append(cc.waiters[:i], cc.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The slot was handed over while canceling; pass it on.
+		cc.inflight--
+		cc.wakeLocked()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot
+func (cc *concurrencyController) release() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.inflight--
+	cc.wakeLocked()
+}
+
+// wakeLocked hands free slots to waiters in arrival order
+func (cc *concurrencyController) wakeLocked() {
+	for len(cc.waiters) > 0 && cc.inflight < cc.current() {
+		cc.inflight++
+		close(cc.waiters[0])
+		cc.waiters = cc.waiters[1:]
+	}
+}
+
+// observe adapts the limit to a response. resp is nil for transport errors,
+// which leave the limit unchanged.
+func (cc *concurrencyController) observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		cc.limit = max(cc.min, cc.limit/2)
+	case nearlyExhausted(resp.Header, "requests") || nearlyExhausted(resp.Header, "tokens"):
+		cc.limit = max(cc.min, cc.limit-1)
+	case resp.StatusCode < 300:
+		cc.limit = min(cc.max, cc.limit+1/cc.limit)
+	}
+	cc.wakeLocked()
+}
+
+// nearlyExhausted reports whether the x-ratelimit headers for kind show less
+// than lowRemainingFraction of the window left
+func nearlyExhausted(h http.Header, kind string) bool {
+	remaining, err := strconv.ParseFloat(h.Get("X-Ratelimit-Remaining-"+kind), 64)
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.ParseFloat(h.Get("X-Ratelimit-Limit-"+kind), 64)
+	if err != nil || limit <= 0 {
+		return false
+	}
+	return remaining/limit < lowRemainingFraction
+}
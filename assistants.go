@@ -0,0 +1,113 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// assistantsBeta is the beta feature the assistants, threads, and runs
+// endpoints require
+const assistantsBeta = "assistants=v2"
+
+// CodeInterpreterTool returns the built-in code interpreter tool of assistants
+func CodeInterpreterTool() Tool {
+	return Tool{Type: ToolTypeCodeInterpreter}
+}
+
+// FileSearchTool returns the built-in file search tool of assistants
+func FileSearchTool() Tool {
+	return Tool{Type: ToolTypeFileSearch}
+}
+
+// ToolResources gives the built-in tools of an assistant or thread their
+// files
+type ToolResources struct {
+	CodeInterpreter *CodeInterpreterResources `json:"code_interpreter,omitempty"`
+	FileSearch      *FileSearchResources      `json:"file_search,omitempty"`
+}
+
+// CodeInterpreterResources lists the files the code interpreter can read
+type CodeInterpreterResources struct {
+	FileIDs []string `json:"file_ids,omitempty"`
+}
+
+// FileSearchResources lists the vector stores file search queries
+type FileSearchResources struct {
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`
+}
+
+// AssistantRequest creates or modifies an assistant. Empty fields are
+// omitted, so modifying leaves them unchanged.
+type AssistantRequest struct {
+	Model        string `json:"model,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	// Tools mixes function tools, built by FunctionTool, with
+	// CodeInterpreterTool and FileSearchTool
+	Tools           []Tool            `json:"tools,omitempty"`
+	ToolResources   *ToolResources    `json:"tool_resources,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Temperature     *float32          `json:"temperature,omitempty"`
+	TopP            *float32          `json:"top_p,omitempty"`
+	ReasoningEffort string            `json:"reasoning_effort,omitempty"`
+	ResponseFormat  *ResponseFormat   `json:"response_format,omitempty"`
+}
+
+// Assistant is an assistant configured for runs on threads
+type Assistant struct {
+	ID              string            `json:"id"`
+	Object          string            `json:"object"`
+	CreatedAt       int64             `json:"created_at"`
+	Model           string            `json:"model"`
+	Name            string            `json:"name,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	Instructions    string            `json:"instructions,omitempty"`
+	Tools           []Tool            `json:"tools"`
+	ToolResources   *ToolResources    `json:"tool_resources,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Temperature     *float32          `json:"temperature,omitempty"`
+	TopP            *float32          `json:"top_p,omitempty"`
+	ReasoningEffort string            `json:"reasoning_effort,omitempty"`
+}
+
+// CreateAssistant creates an assistant
+func (c *Client) CreateAssistant(ctx context.Context, req AssistantRequest) (*Assistant, error) {
+	var assistant Assistant
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, "/assistants", req, &assistant); err != nil {
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// GetAssistant retrieves an assistant
+func (c *Client) GetAssistant(ctx context.Context, id string) (*Assistant, error) {
+	var assistant Assistant
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodGet, "/assistants/"+url.PathEscape(id), nil, &assistant); err != nil {
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// ModifyAssistant updates the fields of an assistant set in req. Tools and
+// ToolResources, when set, replace the existing ones.
+func (c *Client) ModifyAssistant(ctx context.Context, id string, req AssistantRequest) (*Assistant, error) {
+	var assistant Assistant
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, "/assistants/"+url.PathEscape(id), req, &assistant); err != nil {
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// DeleteAssistant deletes an assistant
+func (c *Client) DeleteAssistant(ctx context.Context, id string) error {
+	return c.Do(withBeta(ctx, assistantsBeta), http.MethodDelete, "/assistants/"+url.PathEscape(id), nil, nil)
+}
+
+// ListAssistants lists assistants
+func (c *Client) ListAssistants(opts ListOptions) *Pager[Assistant] {
+	p := NewPager(c, "/assistants", opts, func(a Assistant) string { return a.ID })
+	p.beta = assistantsBeta
+	return p
+}
@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Output modalities of a chat completion
+const (
+	ModalityText  = "text"
+	ModalityAudio = "audio"
+)
+
+// Audio output formats. Streams only support AudioFormatPCM16, raw 16-bit
+// little-endian mono samples at 24 kHz.
+const (
+	AudioFormatWAV   = "wav"
+	AudioFormatMP3   = "mp3"
+	AudioFormatFLAC  = "flac"
+	AudioFormatOpus  = "opus"
+	AudioFormatPCM16 = "pcm16"
+)
+
+// AudioOptions configures the spoken answer of a request whose Modalities
+// include ModalityAudio
+type AudioOptions struct {
+	// Voice is such as "alloy", "ash", "coral", "sage", or "verse"
+	Voice string `json:"voice"`
+	// Format is one of the AudioFormat constants
+	Format string `json:"format"`
+}
+
+// MessageAudio is the spoken answer of an assistant message. In stream
+// deltas it holds a fragment: Data and Transcript continue those of the
+// previous chunks.
+type MessageAudio struct {
+	// ID refers to the audio in later turns of the conversation
+	ID string `json:"id,omitempty"`
+	// Data is the base64-encoded audio in the requested format
+	Data string `json:"data,omitempty"`
+	// ExpiresAt is the Unix time after which ID can no longer be referenced
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// Transcript is the text of the audio
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// Bytes decodes Data
+func (a *MessageAudio) Bytes() ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(a.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return data, nil
+}
+
+// WriteTo writes the decoded audio to w
+func (a *MessageAudio) WriteTo(w io.Writer) (int64, error) {
+	data, err := a.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// SaveFile writes the decoded audio to the file at path, replacing it
+func (a *MessageAudio) SaveFile(path string) error {
+	data, err := a.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	return nil
+}
+
+// WriteAudio reads the rest of the stream and writes the audio of the first
+// choice to w as it arrives. It returns the audio's ID, expiry, and full
+// transcript; Data is left empty, since the audio went to w. The stream is not
+// closed.
+func (s *StreamReader) WriteAudio(w io.Writer) (*MessageAudio, error) {
+	audio := &MessageAudio{}
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			return audio, nil
+		}
+		if err != nil {
+			return audio, err
+		}
+		choice := firstChoice(chunk)
+		if choice == nil || choice.Delta.Audio == nil {
+			continue
+		}
+		if err := audio.append(choice.Delta.Audio, w); err != nil {
+			return audio, err
+		}
+	}
+}
+
+// append merges the stream fragment delta into a, writing its decoded audio to
+// w
+func (a *MessageAudio) append(delta *MessageAudio, w io.Writer) error {
+	if delta.ID != "" {
+		a.ID = delta.ID
+	}
+	if delta.ExpiresAt != 0 {
+		a.ExpiresAt = delta.ExpiresAt
+	}
+	a.Transcript += delta.Transcript
+	if delta.Data == "" {
+		return nil
+	}
+	data, err := delta.Bytes()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	return nil
+}
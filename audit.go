@@ -0,0 +1,150 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one API call: the request body and the response body,
+// which for streaming calls is the raw SSE transcript
+type AuditRecord struct {
+	Time          time.Time     `json:"time"`
+	CorrelationID string        `json:"correlation_id"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+	StatusCode    int           `json:"status_code,omitempty"`
+	Stream        bool          `json:"stream,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	Request       string        `json:"request,omitempty"`
+	Response      string        `json:"response,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// AuditSink stores audit records. Records are written when the response body
+// is closed, so implementations should be fast or buffer; they must be safe
+// for concurrent use.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditOptions configures the audit log
+type AuditOptions struct {
+	// Redact rules, such as DefaultRedactionRules, are applied in order before
+	// records reach the sink
+	Redact []RedactionRule
+	// OnError receives sink failures; by default they are dropped so auditing
+	// never fails a call
+	OnError func(error)
+}
+
+// WithAuditLog records every request and response to sink. Bodies other than
+// JSON, text, and SSE, such as file uploads, are noted but not stored.
+func WithAuditLog(sink AuditSink, opts AuditOptions) ClientOption {
+	return func(c *Client) {
+		c.audit = &auditLog{sink: sink, opts: opts, redactor: NewRedactor(opts.Redact...)}
+	}
+}
+
+// auditLog builds records and hands them to the sink
+type auditLog struct {
+	sink     AuditSink
+	opts     AuditOptions
+	redactor *Redactor
+}
+
+// write redacts and stores record
+func (a *auditLog) write(ctx context.Context, record AuditRecord) {
+	record.Request = a.redactor.Redact(record.Request)
+	record.Response = a.redactor.Redact(record.Response)
+	record.Error = a.redactor.Redact(record.Error)
+	if err := a.sink.WriteAudit(context.WithoutCancel(ctx), record); err != nil && a.opts.OnError != nil {
+		a.opts.OnError(fmt.Errorf("failed to write audit record: %w", err))
+	}
+}
+
+// auditable reports whether bodies of contentType are stored verbatim
+func auditable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" ||
+		mediaType == "text/event-stream" ||
+		strings.HasPrefix(mediaType, "text/")
+}
+
+// auditRequestBody reads body for the record and returns a replacement
+// reader for sending
+func auditRequestBody(contentType string, body io.Reader) (string, io.Reader, error) {
+	if body == nil {
+		return "", nil, nil
+	}
+	if !auditable(contentType) {
+		return "[" + contentType + " body omitted]", body, nil
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return string(data), bytes.NewReader(data), nil
+}
+
+// auditBody copies the response body as it is read and writes the record
+// when it is closed
+type auditBody struct {
+	io.ReadCloser
+	log    *auditLog
+	ctx    context.Context
+	record AuditRecord
+	start  time.Time
+
+	// mu guards buf, since streams may be closed while being read
+	mu   sync.Mutex
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+// newAuditBody wraps resp.Body so the record is completed on close
+func newAuditBody(ctx context.Context, log *auditLog, record AuditRecord, start time.Time, resp *http.Response) io.ReadCloser {
+	b := &auditBody{ReadCloser: resp.Body, log: log, ctx: ctx, record: record, start: start}
+	contentType := resp.Header.Get("Content-Type")
+	if auditable(contentType) {
+		b.buf = &bytes.Buffer{}
+	} else {
+		b.record.Response = "[" + contentType + " body omitted]"
+	}
+	return b
+}
+
+// Read implements io.Reader
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if b.buf != nil {
+		b.buf.Write(p[:n])
+	}
+	b.mu.Unlock()
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *auditBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.record.Duration = time.Since(b.start)
+		b.mu.Lock()
+		if b.buf != nil {
+			b.record.Response = b.buf.String()
+		}
+		b.mu.Unlock()
+		b.log.write(b.ctx, b.record)
+	})
+	return err
+}
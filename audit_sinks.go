@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// FileAuditSink appends audit records to a file as JSON lines
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens path for appending, creating it readable by the
+// owner only
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// WriteAudit implements AuditSink
+func (s *FileAuditSink) WriteAudit(_ context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// Flush implements Flusher by syncing the file to disk
+func (s *FileAuditSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// HTTPAuditSink posts each audit record as JSON to an HTTP endpoint
+type HTTPAuditSink struct {
+	url    string
+	client *http.Client
+	header http.Header
+}
+
+// NewHTTPAuditSink creates a sink posting to url. header is added to every
+// request, for example to authenticate with the collector, and may be nil.
+func NewHTTPAuditSink(url string, header http.Header) *HTTPAuditSink {
+	return &HTTPAuditSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		header: header,
+	}
+}
+
+// WriteAudit implements AuditSink
+func (s *HTTPAuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create audit request: %w", err)
+	}
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit record: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("audit endpoint returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// sqlIdentifierRe matches table names that are safe to interpolate
+var sqlIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLAuditSink inserts audit records into a database table. It works with
+// drivers using "?" placeholders, such as SQLite and MySQL; the caller opens
+// db with the driver of their choice.
+type SQLAuditSink struct {
+	db     *sql.DB
+	insert string
+}
+
+// NewSQLAuditSink creates table in db if it does not exist and returns a sink
+// writing to it
+func NewSQLAuditSink(ctx context.Context, db *sql.DB, table string) (*SQLAuditSink, error) {
+	if !sqlIdentifierRe.MatchString(table) {
+		return nil, fmt.Errorf("invalid audit table name %q", table)
+	}
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+		time TEXT NOT NULL,
+		correlation_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status_code INTEGER,
+		stream INTEGER,
+		duration_ms INTEGER,
+		request TEXT,
+		response TEXT,
+		error TEXT
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return &SQLAuditSink{
+		db: db,
+		insert: `INSERT INTO ` + table + ` (time, correlation_id, method, path, status_code,
+			stream, duration_ms, request, response, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	}, nil
+}
+
+// WriteAudit implements AuditSink
+func (s *SQLAuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	_, err := s.db.ExecContext(ctx, s.insert,
+		record.Time.UTC().Format(time.RFC3339Nano),
+		record.CorrelationID,
+		record.Method,
+		record.Path,
+		record.StatusCode,
+		record.Stream,
+		record.Duration.Milliseconds(),
+		record.Request,
+		record.Response,
+		record.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit record: %w", err)
+	}
+	return nil
+}
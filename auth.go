@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenProvider supplies the bearer token sent with each request, so
+// short-lived credentials from secret managers or OAuth flows can rotate
+// without recreating the client. Token is called once per request and must be
+// safe for concurrent use.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts a function to the TokenProvider interface
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token calls f
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// staticToken is the provider for a fixed API key
+type staticToken string
+
+// Token returns the key
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// WithTokenProvider authenticates requests with tokens from p instead of the
+// API key passed to NewClient, which may then be empty.
+func WithTokenProvider(p TokenProvider) ClientOption {
+	return func(c *Client) {
+		c.tokens = p
+	}
+}
+
+// CredentialsFunc adapts a secret manager lookup that takes no context, such
+// as func() (string, error) from a Vault or Secrets Manager SDK, to the
+// TokenProvider interface
+type CredentialsFunc func() (string, error)
+
+// Token calls f
+func (f CredentialsFunc) Token(context.Context) (string, error) {
+	return f()
+}
+
+// CacheTokens returns a provider that reuses the token from p for ttl before
+// fetching it again, so a rotated key is picked up without hitting the secret
+// store on every request. Failed fetches are not cached.
+func CacheTokens(p TokenProvider, ttl time.Duration) TokenProvider {
+	return &cachedToken{provider: p, ttl: ttl}
+}
+
+// cachedToken is the provider returned by CacheTokens
+type cachedToken struct {
+	provider TokenProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	token   string
+	fetched time.Time
+}
+
+// Token returns the cached token, fetching a new one once it is stale
+func (c *cachedToken) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Since(c.fetched) < c.ttl {
+		return c.token, nil
+	}
+
+	token, err := c.provider.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.fetched = token, time.Now()
+	return token, nil
+}
+
+// RequestSigner adds signatures or other auth material required by gateways,
+// such as HMAC headers. SignRequest is called once per call with the final
+// request, after every header is set and just before it is sent; req.GetBody,
+// when non-nil, returns a copy of the body to hash. It must be safe for
+// concurrent use.
+type RequestSigner interface {
+	SignRequest(req *http.Request) error
+}
+
+// RequestSignerFunc adapts a function to the RequestSigner interface
+type RequestSignerFunc func(req *http.Request) error
+
+// SignRequest calls f
+func (f RequestSignerFunc) SignRequest(req *http.Request) error {
+	return f(req)
+}
+
+// WithRequestSigner signs every request with s. A signing error fails the
+// call without sending it.
+func WithRequestSigner(s RequestSigner) ClientOption {
+	return func(c *Client) {
+		c.signer = s
+	}
+}
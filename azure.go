@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AzureCognitiveServicesScope is the Microsoft Entra ID scope for Azure OpenAI
+const AzureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+// azureRefreshMargin refreshes tokens this long before they expire so that
+// requests in flight never carry an expired token
+const azureRefreshMargin = 5 * time.Minute
+
+// AccessToken is a bearer token and its expiry
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// AzureCredential obtains Microsoft Entra ID (Azure AD) access tokens. Wrap an
+// azidentity credential in a few lines to satisfy it without this package
+// depending on the Azure SDK.
+type AzureCredential interface {
+	GetToken(ctx context.Context, scopes []string) (AccessToken, error)
+}
+
+// WithAzureCredential authenticates requests with Microsoft Entra ID tokens
+// from cred instead of the static API key, for Azure OpenAI deployments. It
+// installs a TokenProvider that caches tokens and refreshes them shortly before
+// they expire. Combine it with WithAPIVersion for Azure endpoints.
+func WithAzureCredential(cred AzureCredential) ClientOption {
+	return func(c *Client) {
+		c.tokens = &azureTokenCache{cred: cred}
+	}
+}
+
+// azureTokenCache is a TokenProvider caching the current Entra ID token
+type azureTokenCache struct {
+	cred AzureCredential
+
+	mu    sync.Mutex
+	token AccessToken
+}
+
+// Token returns a cached token, fetching a new one when it is about to expire
+func (a *azureTokenCache) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token.Token != "" && time.Until(a.token.ExpiresOn) > azureRefreshMargin {
+		return a.token.Token, nil
+	}
+
+	token, err := a.cred.GetToken(ctx, []string{AzureCognitiveServicesScope})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Azure access token: %w", err)
+	}
+	a.token = token
+	return token.Token, nil
+}
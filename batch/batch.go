@@ -0,0 +1,422 @@
+// Package batch schedules request sets through the OpenAI Batch API.
+//
+// A Scheduler accepts jobs of chat completion or embedding requests, submits
+// them during an off-peak window, polls the batches until they finish, and
+// delivers the parsed results to a callback or an output directory. Batches
+// cost less than synchronous requests in exchange for completing within a
+// day, which suits cost-sensitive pipelines.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiyeol-lee/openai"
+)
+
+// Endpoints a job can target
+const (
+	EndpointChat       = "/v1/chat/completions"
+	EndpointEmbeddings = "/v1/embeddings"
+)
+
+// defaultPollInterval is how often running batches are checked
+const defaultPollInterval = time.Minute
+
+// Request is a single request of a job
+type Request struct {
+	// CustomID identifies the request's result and must be unique in the job
+	CustomID string
+	// Body is the request, such as an openai.ChatCompletionRequest
+	Body any
+}
+
+// Job is a set of requests submitted as one batch
+type Job struct {
+	// Name labels the job in results, errors, and output file names; it must
+	// be non-empty and free of path separators
+	Name string
+	// Endpoint is EndpointChat or EndpointEmbeddings
+	Endpoint string
+	Requests []Request
+	// OnResult, when set, receives every result of the job
+	OnResult func(Result)
+	// OutputDir, when set, receives the raw result lines as <Name>.jsonl
+	OutputDir string
+	// Metadata is attached to the batch
+	Metadata map[string]string
+}
+
+// Result is the outcome of one request of a job
+type Result struct {
+	Job        string
+	CustomID   string
+	StatusCode int
+	// Body is the response body of the request
+	Body json.RawMessage
+	// Err reports a request that failed
+	Err error
+}
+
+// ChatCompletion decodes a chat completion result
+func (r Result) ChatCompletion() (*openai.ChatCompletionResponse, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(r.Body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode batch result: %w", err)
+	}
+	return &resp, nil
+}
+
+// Embeddings decodes an embeddings result
+func (r Result) Embeddings() (*openai.EmbeddingResponse, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var resp openai.EmbeddingResponse
+	if err := json.Unmarshal(r.Body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode batch result: %w", err)
+	}
+	return &resp, nil
+}
+
+// Window is a daily time range, in hours, during which jobs are submitted.
+// It may wrap around midnight, such as 22 to 6. The zero Window is always
+// open.
+type Window struct {
+	Start, End int
+	// Location defaults to the local time zone
+	Location *time.Location
+}
+
+// open reports whether t falls inside the window
+func (w Window) open(t time.Time) bool {
+	if w.Start == w.End {
+		return true
+	}
+	if w.Location != nil {
+		t = t.In(w.Location)
+	}
+	hour := t.Hour()
+	if w.Start < w.End {
+		return hour >= w.Start && hour < w.End
+	}
+	return hour >= w.Start || hour < w.End
+}
+
+// nextOpen returns the next time at or after t when the window opens
+func (w Window) nextOpen(t time.Time) time.Time {
+	if w.open(t) {
+		return t
+	}
+	if w.Location != nil {
+		t = t.In(w.Location)
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), w.Start, 0, 0, 0, t.Location())
+	if !start.After(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+// Options configures a Scheduler
+type Options struct {
+	// OffPeak restricts submissions to a daily window
+	OffPeak Window
+	// PollInterval is how often running batches are checked; defaults to 1m
+	PollInterval time.Duration
+	// OnError, when set, receives errors that end a job, such as a failed
+	// upload or an expired batch
+	OnError func(job string, err error)
+}
+
+// Scheduler submits and tracks batch jobs
+type Scheduler struct {
+	client *openai.Client
+	opts   Options
+	wake   chan struct{}
+
+	mu      sync.Mutex
+	queued  []Job
+	running []running
+}
+
+// running is a submitted job and its batch
+type running struct {
+	job     Job
+	batchID string
+}
+
+// NewScheduler creates a scheduler that submits jobs through c
+func NewScheduler(c *openai.Client, opts Options) *Scheduler {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	return &Scheduler{client: c, opts: opts, wake: make(chan struct{}, 1)}
+}
+
+// Submit queues a job for the next submission window
+func (s *Scheduler) Submit(job Job) error {
+	if job.Name == "" {
+		return errors.New("batch job has no name")
+	}
+	if strings.ContainsAny(job.Name, `/\`) {
+		return fmt.Errorf("batch job name %q contains a path separator", job.Name)
+	}
+	if job.Endpoint == "" {
+		return errors.New("batch job has no endpoint")
+	}
+	if len(job.Requests) == 0 {
+		return errors.New("batch job has no requests")
+	}
+	seen := make(map[string]bool, len(job.Requests))
+	for _, req := range job.Requests {
+		if req.CustomID == "" || seen[req.CustomID] {
+			return fmt.Errorf("batch job %q: custom IDs must be unique and non-empty", job.Name)
+		}
+		seen[req.CustomID] = true
+	}
+
+	s.mu.Lock()
+	s.queued = append(s.queued, job)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending returns the number of jobs queued or running
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queued) + len(s.running)
+}
+
+// Run submits queued jobs while the off-peak window is open and polls running
+// batches until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		now := time.Now()
+		if s.opts.OffPeak.open(now) {
+			s.submitQueued(ctx)
+		}
+		s.pollRunning(ctx)
+
+		wait := s.opts.PollInterval
+		s.mu.Lock()
+		if len(s.running) == 0 && len(s.queued) > 0 {
+			wait = time.Until(s.opts.OffPeak.nextOpen(time.Now()))
+		}
+		idle := len(s.running) == 0 && len(s.queued) == 0
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		if idle {
+			timer.Stop()
+		}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// submitQueued uploads and starts every queued job
+func (s *Scheduler) submitQueued(ctx context.Context) {
+	s.mu.Lock()
+	jobs := s.queued
+	s.queued = nil
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		batchID, err := s.start(ctx, job)
+		if err != nil {
+			s.fail(job.Name, err)
+			continue
+		}
+		s.mu.Lock()
+		s.running = append(s.running, running{job: job, batchID: batchID})
+		s.mu.Unlock()
+	}
+}
+
+// start uploads the job's requests as JSONL and creates the batch
+func (s *Scheduler) start(ctx context.Context, job Job) (string, error) {
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, req := range job.Requests {
+		line := struct {
+			CustomID string `json:"custom_id"`
+			Method   string `json:"method"`
+			URL      string `json:"url"`
+			Body     any    `json:"body"`
+		}{req.CustomID, "POST", job.Endpoint, req.Body}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("failed to encode request %q: %w", req.CustomID, err)
+		}
+	}
+
+	file, err := s.client.UploadFile(ctx, job.Name+".jsonl", openai.FilePurposeBatch, &input)
+	if err != nil {
+		return "", err
+	}
+	batch, err := s.client.CreateBatch(ctx, openai.BatchRequest{
+		InputFileID: file.ID,
+		Endpoint:    job.Endpoint,
+		Metadata:    job.Metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+	return batch.ID, nil
+}
+
+// pollRunning checks every running batch and delivers finished ones. Batches
+// delivered before ctx ends are dropped from the running set, so they are not
+// delivered again.
+func (s *Scheduler) pollRunning(ctx context.Context) {
+	s.mu.Lock()
+	runs := slices.Clone(s.running)
+	s.mu.Unlock()
+
+	delivered := make(map[string]bool)
+	defer func() {
+		s.mu.Lock()
+		s.running = slices.DeleteFunc(s.running, func(r running) bool { return delivered[r.batchID] })
+		s.mu.Unlock()
+	}()
+
+	for _, run := range runs {
+		batch, err := s.client.GetBatch(ctx, run.batchID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if !batch.Done() {
+			continue
+		}
+		delivered[run.batchID] = true
+		if err := s.deliver(ctx, run.job, batch); err != nil {
+			s.fail(run.job.Name, err)
+		}
+	}
+}
+
+// deliver downloads the results of a finished batch and hands them out
+func (s *Scheduler) deliver(ctx context.Context, job Job, batch *openai.Batch) error {
+	if batch.OutputFileID == "" && batch.ErrorFileID == "" {
+		return fmt.Errorf("batch %s ended with status %s", batch.ID, batch.Status)
+	}
+
+	var out io.Writer = io.Discard
+	if job.OutputDir != "" {
+		f, err := os.Create(filepath.Join(job.OutputDir, job.Name+".jsonl"))
+		if err != nil {
+			return fmt.Errorf("failed to create batch output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := s.deliverFile(ctx, job, fileID, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverFile parses one result file, copying its lines to out
+func (s *Scheduler) deliverFile(ctx context.Context, job Job, fileID string, out io.Writer) error {
+	content, err := s.client.GetFileContent(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "%s\n", line); err != nil {
+			return fmt.Errorf("failed to write batch output: %w", err)
+		}
+		result, err := parseResult(job.Name, line)
+		if err != nil {
+			return err
+		}
+		if job.OnResult != nil {
+			job.OnResult(result)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read batch results: %w", err)
+	}
+	return nil
+}
+
+// parseResult decodes one line of a batch output or error file
+func parseResult(job string, line []byte) (Result, error) {
+	var raw struct {
+		CustomID string `json:"custom_id"`
+		Response *struct {
+			StatusCode int             `json:"status_code"`
+			Body       json.RawMessage `json:"body"`
+		} `json:"response"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return Result{}, fmt.Errorf("failed to decode batch result: %w", err)
+	}
+
+	result := Result{Job: job, CustomID: raw.CustomID}
+	if raw.Response != nil {
+		result.StatusCode = raw.Response.StatusCode
+		result.Body = raw.Response.Body
+	}
+	switch {
+	case raw.Error != nil:
+		result.Err = fmt.Errorf("batch request failed: %s: %s", raw.Error.Code, raw.Error.Message)
+	case result.StatusCode >= 300:
+		result.Err = fmt.Errorf("batch request failed (status %d): %s", result.StatusCode, result.Body)
+	}
+	return result, nil
+}
+
+// fail reports an error that ended a job
+func (s *Scheduler) fail(job string, err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(job, err)
+	}
+}
@@ -0,0 +1,115 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Batch statuses
+const (
+	BatchValidating = "validating"
+	BatchFailed     = "failed"
+	BatchInProgress = "in_progress"
+	BatchFinalizing = "finalizing"
+	BatchCompleted  = "completed"
+	BatchExpired    = "expired"
+	BatchCancelling = "cancelling"
+	BatchCancelled  = "cancelled"
+)
+
+// BatchRequest creates a batch from an uploaded JSONL input file
+type BatchRequest struct {
+	InputFileID string `json:"input_file_id"`
+	// Endpoint is the API path every request line targets, such as
+	// "/v1/chat/completions"
+	Endpoint string `json:"endpoint"`
+	// CompletionWindow defaults to "24h", the only window currently offered
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r BatchRequest) MarshalJSON() ([]byte, error) {
+	type alias BatchRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// Batch describes a batch job
+type Batch struct {
+	ID               string `json:"id"`
+	Object           string `json:"object"`
+	Endpoint         string `json:"endpoint"`
+	InputFileID      string `json:"input_file_id"`
+	CompletionWindow string `json:"completion_window"`
+	Status           string `json:"status"`
+	OutputFileID     string `json:"output_file_id,omitempty"`
+	ErrorFileID      string `json:"error_file_id,omitempty"`
+	CreatedAt        int64  `json:"created_at"`
+	CompletedAt      int64  `json:"completed_at,omitempty"`
+	RequestCounts    struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Line    int    `json:"line,omitempty"`
+		} `json:"data"`
+	} `json:"errors,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Done reports whether the batch reached a final status
+func (b *Batch) Done() bool {
+	switch b.Status {
+	case BatchCompleted, BatchFailed, BatchExpired, BatchCancelled:
+		return true
+	}
+	return false
+}
+
+// CreateBatch starts a batch job
+func (c *Client) CreateBatch(ctx context.Context, req BatchRequest) (*Batch, error) {
+	if req.CompletionWindow == "" {
+		req.CompletionWindow = "24h"
+	}
+	var batch Batch
+	if err := c.Do(ctx, http.MethodPost, "/batches", req, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatch retrieves a batch job
+func (c *Client) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.Do(ctx, http.MethodGet, "/batches/"+url.PathEscape(id), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelBatch cancels a batch job in progress
+func (c *Client) CancelBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.Do(ctx, http.MethodPost, "/batches/"+url.PathEscape(id)+"/cancel", nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches lists batch jobs
+func (c *Client) ListBatches(opts ListOptions) *Pager[Batch] {
+	return NewPager(c, "/batches", opts, func(b Batch) string { return b.ID })
+}
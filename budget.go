@@ -0,0 +1,219 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// ErrBudgetExceeded is returned when a call would exceed a configured budget
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget limits spending over rolling windows. A zero limit disables that
+// window. Costs come from the pricing table; models missing from it are not
+// counted.
+type Budget struct {
+	MaxUSDPerHour float64
+	MaxUSDPerDay  float64
+	// Pricing overrides or extends DefaultPricing
+	Pricing map[string]ModelPrice
+	// StopStreams ends in-flight streams at the next chunk boundary once the
+	// estimated spend crosses a limit
+	StopStreams bool
+}
+
+// Spend reports the USD spent within the rolling budget windows
+type Spend struct {
+	LastHour float64
+	LastDay  float64
+}
+
+// WithBudget enforces spending limits. Calls made while a limit is exhausted
+// fail with ErrBudgetExceeded.
+func WithBudget(b Budget) ClientOption {
+	return func(c *Client) {
+		b.Pricing = maps.Clone(b.Pricing)
+		c.budget = &budgetTracker{cfg: b}
+	}
+}
+
+// Spend returns the spend recorded by the budget tracker, or zero when no
+// budget is configured
+func (c *Client) Spend() Spend {
+	if c.budget == nil {
+		return Spend{}
+	}
+	return c.budget.spend()
+}
+
+type spendEntry struct {
+	at  time.Time
+	usd float64
+}
+
+// budgetTracker records spend and enforces Budget limits
+type budgetTracker struct {
+	cfg     Budget
+	mu      sync.Mutex
+	entries []spendEntry
+	// open holds the streams whose spend is not recorded yet
+	open map[*streamSpend]struct{}
+}
+
+// cost prices a call
+func (b *budgetTracker) cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := lookupPrice(model, b.cfg.Pricing)
+	if !ok {
+		return 0
+	}
+	return price.Cost(promptTokens, completionTokens)
+}
+
+// record adds spend at the current time
+func (b *budgetTracker) record(usd float64) {
+	if usd <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, spendEntry{at: time.Now(), usd: usd})
+}
+
+// check reports ErrBudgetExceeded when the recorded spend plus pending would
+// exceed a limit
+func (b *budgetTracker) check(pending float64) error {
+	spend := b.spend()
+	if limit := b.cfg.MaxUSDPerHour; limit > 0 && spend.LastHour+pending >= limit {
+		return fmt.Errorf("%w: $%.4f of $%.2f spent in the last hour", ErrBudgetExceeded, spend.LastHour+pending, limit)
+	}
+	if limit := b.cfg.MaxUSDPerDay; limit > 0 && spend.LastDay+pending >= limit {
+		return fmt.Errorf("%w: $%.4f of $%.2f spent in the last day", ErrBudgetExceeded, spend.LastDay+pending, limit)
+	}
+	return nil
+}
+
+// spend sums the windows, dropping entries older than a day
+func (b *budgetTracker) spend() Spend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	keep := b.entries[:0]
+	var s Spend
+	for _, e := range b.entries {
+		age := now.Sub(e.at)
+		if age > 24*time.Hour {
+			continue
+		}
+		keep = append(keep, e)
+		s.LastDay += e.usd
+		if age <= time.Hour {
+			s.LastHour += e.usd
+		}
+	}
+	b.entries = keep
+	return s
+}
+
+// streamSpend estimates the cost of a stream from its prompt and streamed
+// text, since streamed responses carry no usage by default. The usage of
+// streams requested with IncludeUsage replaces the estimate.
+type streamSpend struct {
+	mu           sync.Mutex
+	tracker      *budgetTracker
+	model        string
+	promptTokens int
+	output       token.Counter
+	settled      bool
+}
+
+// newStreamSpend prepares spend accounting for a streaming request
+func newStreamSpend(tracker *budgetTracker, req ChatCompletionRequest) *streamSpend {
+	prompt := replyPrimingTokens
+	for _, m := range req.Messages {
+		prompt += MessageTokens(m)
+	}
+	s := &streamSpend{tracker: tracker, model: req.Model, promptTokens: prompt}
+	tracker.mu.Lock()
+	if tracker.open == nil {
+		tracker.open = make(map[*streamSpend]struct{})
+	}
+	tracker.open[s] = struct{}{}
+	tracker.mu.Unlock()
+	return s
+}
+
+// Flush records the estimated spend of streams still open, such as those
+// left running when Client.Close gave up waiting for them
+func (b *budgetTracker) Flush() error {
+	b.mu.Lock()
+	open := make([]*streamSpend, 0, len(b.open))
+	for s := range b.open {
+		open = append(open, s)
+	}
+	b.mu.Unlock()
+	for _, s := range open {
+		s.settle()
+	}
+	return nil
+}
+
+// add accounts for streamed text and, when StopStreams is set, reports
+// ErrBudgetExceeded once the running estimate crosses a limit
+func (s *streamSpend) add(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output.Add(text)
+	if !s.tracker.cfg.StopStreams || s.settled {
+		return nil
+	}
+	if err := s.tracker.check(s.estimate()); err != nil {
+		s.settleLocked()
+		return err
+	}
+	return nil
+}
+
+func (s *streamSpend) estimate() float64 {
+	return s.tracker.cost(s.model, s.promptTokens, s.output.Tokens())
+}
+
+// settleUsage records the cost of the usage the API reported in place of
+// the estimate
+func (s *streamSpend) settleUsage(usage Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settled {
+		return
+	}
+	s.settled = true
+	s.tracker.record(s.tracker.cost(s.model, usage.PromptTokens, usage.CompletionTokens))
+	s.tracker.closeStream(s)
+}
+
+// settle records the estimated spend exactly once
+func (s *streamSpend) settle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settleLocked()
+}
+
+func (s *streamSpend) settleLocked() {
+	if s.settled {
+		return
+	}
+	s.settled = true
+	s.tracker.record(s.estimate())
+	s.tracker.closeStream(s)
+}
+
+// closeStream forgets a stream whose spend was recorded
+func (b *budgetTracker) closeStream(s *streamSpend) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.open, s)
+}
@@ -0,0 +1,103 @@
+package openai
+
+import (
+	"maps"
+	"strings"
+)
+
+// ModelCapabilities describes which request parameters a model accepts
+type ModelCapabilities struct {
+	// Reasoning models accept reasoning_effort and max_completion_tokens but
+	// reject temperature, top_p, the penalties, and logit_bias
+	Reasoning bool
+	// LegacyMaxTokens models only understand max_tokens, not
+	// max_completion_tokens
+	LegacyMaxTokens bool
+}
+
+// DefaultCapabilities holds model capabilities keyed by model name prefix.
+// Dated snapshots resolve to the longest matching prefix; unknown models are
+// assumed to accept every parameter.
+var DefaultCapabilities = map[string]ModelCapabilities{
+	"gpt-5":         {Reasoning: true},
+	"gpt-5-chat":    {},
+	"gpt-4.1":       {},
+	"gpt-4o":        {},
+	"gpt-4-turbo":   {},
+	"gpt-4":         {LegacyMaxTokens: true},
+	"gpt-3.5-turbo": {LegacyMaxTokens: true},
+	"o1":            {Reasoning: true},
+	"o3":            {Reasoning: true},
+	"o4":            {Reasoning: true},
+}
+
+// WithCompatibilityShims rewrites chat requests to fit the model's
+// capabilities instead of letting the API reject them: temperature, top_p,
+// the penalties, and logit_bias are dropped and max_tokens becomes
+// max_completion_tokens for reasoning models, reasoning_effort is dropped for
+// other models, and max_completion_tokens becomes max_tokens for legacy
+// models. overrides take precedence over DefaultCapabilities and may be nil.
+func WithCompatibilityShims(overrides map[string]ModelCapabilities) ClientOption {
+	return func(c *Client) {
+		c.compat = &compatShims{overrides: maps.Clone(overrides)}
+	}
+}
+
+// LookupCapabilities returns the capabilities of model from DefaultCapabilities
+func LookupCapabilities(model string) (ModelCapabilities, bool) {
+	return matchCapabilities(model, DefaultCapabilities)
+}
+
+// compatShims adapts requests to model capabilities
+type compatShims struct {
+	overrides map[string]ModelCapabilities
+}
+
+// lookup consults the overrides before the default table
+func (s *compatShims) lookup(model string) (ModelCapabilities, bool) {
+	if caps, ok := matchCapabilities(model, s.overrides); ok {
+		return caps, true
+	}
+	return matchCapabilities(model, DefaultCapabilities)
+}
+
+func matchCapabilities(model string, table map[string]ModelCapabilities) (ModelCapabilities, bool) {
+	best := ""
+	var found ModelCapabilities
+	for prefix, caps := range table {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, found = prefix, caps
+		}
+	}
+	return found, best != ""
+}
+
+// apply rewrites req for its model; unknown models are left untouched
+func (s *compatShims) apply(req ChatCompletionRequest) ChatCompletionRequest {
+	caps, ok := s.lookup(req.Model)
+	if !ok {
+		return req
+	}
+
+	if caps.Reasoning {
+		req.Temperature = nil
+		req.TopP = nil
+		req.FrequencyPenalty = nil
+		req.PresencePenalty = nil
+		req.LogitBias = nil
+		if req.MaxCompletionTokens == 0 {
+			req.MaxCompletionTokens = req.MaxTokens
+		}
+		req.MaxTokens = 0
+		return req
+	}
+
+	req.ReasoningEffort = ""
+	if caps.LegacyMaxTokens {
+		if req.MaxTokens == 0 {
+			req.MaxTokens = req.MaxCompletionTokens
+		}
+		req.MaxCompletionTokens = 0
+	}
+	return req
+}
@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// sseCapture archives the raw SSE byte stream of streaming calls
+type sseCapture struct {
+	dir    string
+	redact func([]byte) []byte
+	seq    atomic.Uint64
+}
+
+// WithSSECapture writes the exact SSE byte stream of every streaming call to a
+// new file in dir, readable by the owner only, for bug reports and offline
+// replay. When redact is non-nil each line passes through it before being
+// written, so secrets echoed by the model can be scrubbed from the archive.
+func WithSSECapture(dir string, redact func([]byte) []byte) ClientOption {
+	return func(c *Client) {
+		c.capture = &sseCapture{dir: dir, redact: redact}
+	}
+}
+
+// create opens a fresh capture file for one stream
+func (s *sseCapture) create() (*os.File, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create SSE capture directory: %w", err)
+	}
+	name := fmt.Sprintf(
+		"stream-%s-%d.sse",
+		time.Now().UTC().Format("20060102T150405.000000000"),
+		s.seq.Add(1),
+	)
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSE capture file: %w", err)
+	}
+	return file, nil
+}
+
+// captureWriter writes redacted lines into a capture file
+type captureWriter struct {
+	w      io.WriteCloser
+	redact func([]byte) []byte
+}
+
+// writeLine archives a single raw line, ignoring write errors so a full disk
+// never interrupts the stream itself
+func (c *captureWriter) writeLine(line []byte) {
+	if c.redact != nil {
+		line = c.redact(line)
+	}
+	_, _ = c.w.Write(line)
+}
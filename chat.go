@@ -8,60 +8,195 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jiyeol-lee/openai/internal"
 )
 
+// Message roles. Newer models take instructions as RoleDeveloper messages,
+// which replace RoleSystem.
+const (
+	RoleSystem    = "system"
+	RoleDeveloper = "developer"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
 // Message represents a single message in a chat conversation
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Name tells apart participants sharing a role
+	Name string `json:"name,omitempty"`
+	// Parts, when non-empty, is sent instead of Content as structured content
+	Parts MessageContent `json:"-"`
+	// ToolCalls are the calls requested by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a "tool" message with its result to the call
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Refusal explains why the model declined to answer a structured
+	// outputs request; Content is empty then
+	Refusal string `json:"refusal,omitempty"`
+	// Audio is the spoken answer to a request with audio output. Sending the
+	// message back refers to the audio by ID only, as the API expects.
+	Audio *MessageAudio `json:"audio,omitempty"`
+}
+
+// SystemMessage returns a system message with content
+func SystemMessage(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+// DeveloperMessage returns a developer message with content
+func DeveloperMessage(content string) Message {
+	return Message{Role: RoleDeveloper, Content: content}
+}
+
+// UserMessage returns a user message with content
+func UserMessage(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// AssistantMessage returns an assistant message with content
+func AssistantMessage(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// Ptr returns a pointer to v, for optional request fields such as Seed
+func Ptr[T any](v T) *T {
+	return &v
 }
 
 // ChatCompletionRequest represents a chat completion request
 type ChatCompletionRequest struct {
-	Model           string    `json:"model"`
-	Messages        []Message `json:"messages"`
-	Temperature     float32   `json:"temperature,omitempty"`
-	ReasoningEffort string    `json:"reasoning_effort,omitempty"`
-	Stream          bool      `json:"stream,omitempty"`
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	// Temperature, between 0 and 2, and TopP control sampling; zero is a
+	// valid value, so nil leaves the API default
+	Temperature     *float32 `json:"temperature,omitempty"`
+	TopP            *float32 `json:"top_p,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+	// MaxTokens is understood by older models; newer ones use
+	// MaxCompletionTokens, which also counts reasoning tokens
+	MaxTokens           int `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+	// Stop lists up to four sequences at which the model stops generating;
+	// WithStopEmulation also enforces them on the client side
+	Stop []string `json:"stop,omitempty"`
+	// FrequencyPenalty and PresencePenalty, between -2 and 2, discourage
+	// repeating tokens; nil leaves the API default
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float32 `json:"presence_penalty,omitempty"`
+	// Seed makes sampling repeatable on a best-effort basis; zero is a valid
+	// seed, so nil omits it
+	Seed *int `json:"seed,omitempty"`
+	// LogitBias maps token IDs to a bias between -100 and 100
+	LogitBias map[string]int `json:"logit_bias,omitempty"`
+	// N is the number of choices to generate; the methods returning a
+	// single answer use the first
+	N      int  `json:"n,omitempty"`
+	Stream bool `json:"stream,omitempty"`
+	// ServiceTier selects the processing tier, such as "auto", "default",
+	// "flex", or "priority"
+	ServiceTier string `json:"service_tier,omitempty"`
+	// StreamOptions applies to streaming calls only; set IncludeUsage to get
+	// the token usage of a stream
+	StreamOptions *ChatCompletionStreamOptions `json:"stream_options,omitempty"`
+	// Tools are the tools the model may call; ToolChoice is "auto", "none",
+	// "required", or an object naming one tool
+	Tools      []Tool `json:"tools,omitempty"`
+	ToolChoice any    `json:"tool_choice,omitempty"`
+	// ParallelToolCalls set to false limits responses to one tool call, for
+	// tools that must run in order; nil keeps the API default of true
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+	// Modalities lists the output types, such as ModalityText and
+	// ModalityAudio; Audio configures the spoken answer for the latter
+	Modalities []string      `json:"modalities,omitempty"`
+	Audio      *AudioOptions `json:"audio,omitempty"`
+	// ResponseFormat requests JSON output, optionally matching a schema
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Store persists the completion for later retrieval with the stored
+	// completions endpoints, tagged with Metadata
+	Store    bool              `json:"store,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
 }
 
 // ChatCompletionResponse represents the API response for non-streaming requests
 type ChatCompletionResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index        int     `json:"index"`
-		Message      Message `json:"message"`
-		FinishReason string  `json:"finish_reason"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+	// SystemFingerprint identifies the backend configuration; requests with
+	// the same Seed are only reproducible while it stays the same
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	// ServiceTier is the tier that processed the request
+	ServiceTier string `json:"service_tier,omitempty"`
+	// Raw is the undecoded response body, for reading fields the typed
+	// struct does not model
+	Raw json.RawMessage `json:"-"`
+}
+
+// Choice is one of the answers of a chat completion; requests with N > 1
+// return several
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// StreamChoice is the part of a stream chunk belonging to one choice
+type StreamChoice struct {
+	Index        int         `json:"index"`
+	Delta        StreamDelta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+// StreamDelta is the text, tool call, and audio fragments a chunk adds to a
+// choice
+type StreamDelta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+	Audio     *MessageAudio   `json:"audio,omitempty"`
+}
+
+// Usage reports the tokens consumed by a chat completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionStreamOptions configures a streaming request
+type ChatCompletionStreamOptions struct {
+	// IncludeUsage adds a final chunk with Usage and no choices
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // ChatCompletionStreamResponse represents a streaming chunk response
 type ChatCompletionStreamResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index int `json:"index"`
-		Delta struct {
-			Role    string `json:"role,omitempty"`
-			Content string `json:"content,omitempty"`
-		} `json:"delta"`
-		FinishReason *string `json:"finish_reason"`
-	} `json:"choices"`
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []StreamChoice `json:"choices"`
+	// Usage is set on the final chunk of streams requested with
+	// IncludeUsage
+	Usage *Usage `json:"usage,omitempty"`
+	// SystemFingerprint and ServiceTier are as in ChatCompletionResponse
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	ServiceTier       string `json:"service_tier,omitempty"`
+	// Raw is the undecoded data payload of the chunk
+	Raw json.RawMessage `json:"-"`
 }
 
 // StreamReader provides access to streaming chat completion responses
@@ -69,6 +204,48 @@ type StreamReader struct {
 	reader  *bufio.Reader
 	closer  io.Closer
 	isFirst bool
+	capture *captureWriter
+
+	// latency and endpoint receive the time to the first chunk, measured from
+	// start
+	latency  LatencyExporter
+	endpoint string
+	start    time.Time
+
+	// spend estimates the cost of the stream when a budget is configured
+	spend *streamSpend
+
+	correlationID string
+
+	// heartbeats counts SSE comment lines such as ": keep-alive"; onHeartbeat
+	// is invoked for each of them
+	heartbeats  int
+	onHeartbeat func()
+
+	// strict rejects chunks with fields the typed struct does not model
+	strict bool
+
+	// malformed counts frames skipped because they could not be parsed;
+	// strictFrames returns a StreamParseError instead. offset is the number
+	// of bytes read so far.
+	malformed    int
+	strictFrames bool
+	offset       int64
+
+	// stops emulates stop sequences; stopped is set once the stream ended
+	// at one or its held-back text was flushed
+	stops   *stopWatcher
+	stopped bool
+
+	rateLimit RateLimitInfo
+
+	// usage is the usage reported by the final chunk; onUsage receives it
+	usage   *Usage
+	onUsage func(Usage)
+	// closeOnce guards Close, which the markdown pump's cancel callback and
+	// its deferred cleanup may call at the same time
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // deferredCloser allows setting and invoking a close function exactly once,
@@ -108,74 +285,342 @@ func (d *deferredCloser) Close() {
 	}
 }
 
-// Recv reads the next chunk from the stream
+// Heartbeats returns the number of keep-alive comments received so far
+func (s *StreamReader) Heartbeats() int {
+	return s.heartbeats
+}
+
+// MalformedFrames returns the number of frames skipped because they could not
+// be parsed. It stays zero with WithStrictStreamParsing, which fails instead.
+func (s *StreamReader) MalformedFrames() int {
+	return s.malformed
+}
+
+// Usage returns the token usage reported at the end of a stream requested
+// with IncludeUsage, or nil before it arrived or without IncludeUsage
+func (s *StreamReader) Usage() *Usage {
+	return s.usage
+}
+
+// CorrelationID returns the client-side correlation ID of the stream's request
+func (s *StreamReader) CorrelationID() string {
+	return s.correlationID
+}
+
+// NewStreamReader reads a chat completion stream in server-sent events format
+// from r, for replaying captured streams and for test doubles of ChatClient.
+// Closing the stream closes r.
+func NewStreamReader(r io.ReadCloser) *StreamReader {
+	return &StreamReader{
+		reader:    bufio.NewReader(r),
+		closer:    r,
+		isFirst:   true,
+		rateLimit: ParseRateLimitInfo(nil),
+	}
+}
+
+// Recv reads the next chunk from the stream. Errors other than io.EOF carry
+// the correlation ID of the request.
 func (s *StreamReader) Recv() (ChatCompletionStreamResponse, error) {
-	var response ChatCompletionStreamResponse
+	response, err := s.recv()
+	if err != nil && err != io.EOF {
+		err = withCorrelation(s.correlationID, err)
+	}
+	return response, err
+}
+
+func (s *StreamReader) recv() (ChatCompletionStreamResponse, error) {
+	if s.stopped {
+		if s.spend != nil {
+			s.spend.settle()
+		}
+		return ChatCompletionStreamResponse{}, io.EOF
+	}
 
 	for {
 		line, err := s.reader.ReadBytes('\n')
-		if err != nil {
-			return response, err
+		if s.capture != nil && len(line) > 0 {
+			s.capture.writeLine(line)
 		}
+		offset := s.offset
+		s.offset += int64(len(line))
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF {
+				// Release held-back text of a body that ended without
+				// [DONE].
+				if s.stops != nil {
+					if held := s.stops.flush(); held != nil {
+						s.stopped = true
+						return s.deliver(*held)
+					}
+				}
+				return ChatCompletionStreamResponse{}, err
+			}
+			return ChatCompletionStreamResponse{}, classifyReadError(err)
+		}
+		// A final line without a newline is still parsed; the next read
+		// reports io.EOF.
 
 		line = bytes.TrimSpace(line)
 		if len(line) == 0 {
 			continue
 		}
 
-		// SSE format: "data: {...}"
-		if !bytes.HasPrefix(line, []byte("data: ")) {
+		// SSE comments (": keep-alive") keep idle connections open
+		if line[0] == ':' {
+			s.heartbeats++
+			if s.onHeartbeat != nil {
+				s.onHeartbeat()
+			}
 			continue
 		}
 
-		data := bytes.TrimPrefix(line, []byte("data: "))
+		// SSE format: "data: {...}", the space being optional
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimPrefix(data, []byte(" "))
 
 		// Check for stream end
 		if string(data) == "[DONE]" {
-			return response, io.EOF
+			if s.stops != nil {
+				if held := s.stops.flush(); held != nil {
+					s.stopped = true
+					return s.deliver(*held)
+				}
+			}
+			if s.spend != nil {
+				s.spend.settle()
+			}
+			return ChatCompletionStreamResponse{}, io.EOF
 		}
 
-		if err := json.Unmarshal(data, &response); err != nil {
-			return response, fmt.Errorf("failed to decode stream chunk: %w", err)
+		var response ChatCompletionStreamResponse
+		if err := decodeJSON(data, &response, s.strict); err != nil {
+			if errors.Is(err, ErrUnknownFields) {
+				return response, fmt.Errorf("failed to decode stream chunk: %w", err)
+			}
+			parseErr := newStreamParseError(offset, data, err)
+			if s.strictFrames {
+				return ChatCompletionStreamResponse{}, parseErr
+			}
+			// Resynchronize on the next data line.
+			s.malformed++
+			continue
+		}
+
+		if s.stops != nil && s.stops.process(&response) {
+			// Cancel the request; the stream ends after this chunk.
+			s.stopped = true
+			s.closer.Close()
 		}
 
-		return response, nil
+		return s.deliver(response)
 	}
 }
 
-// Close closes the stream
+// deliver records a chunk about to be returned by recv
+func (s *StreamReader) deliver(response ChatCompletionStreamResponse) (ChatCompletionStreamResponse, error) {
+	if s.isFirst && extractDeltaText(response) != "" {
+		s.isFirst = false
+		if s.latency != nil {
+			s.latency.ObserveTimeToFirstToken(s.endpoint, time.Since(s.start))
+		}
+	}
+
+	if response.Usage != nil {
+		s.usage = response.Usage
+		if s.spend != nil {
+			s.spend.settleUsage(*response.Usage)
+		}
+		if s.onUsage != nil {
+			s.onUsage(*response.Usage)
+		}
+	}
+
+	if s.spend != nil {
+		if err := s.spend.add(extractDeltaText(response)); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+// Close closes the stream. A bounded amount of any unread body is drained
+// first so the underlying keep-alive connection can be reused. Close may be
+// called more than once and concurrently; every call returns the error of the
+// first.
 func (s *StreamReader) Close() error {
-	return s.closer.Close()
+	s.closeOnce.Do(func() {
+		if s.spend != nil {
+			s.spend.settle()
+		}
+		if s.capture != nil {
+			s.capture.w.Close()
+		}
+		if body, ok := s.closer.(io.ReadCloser); ok {
+			s.closeErr = drainAndClose(body)
+			return
+		}
+		s.closeErr = s.closer.Close()
+	})
+	return s.closeErr
 }
 
-// CreateChatCompletion sends a non-streaming chat completion request
+// CreateChatCompletion sends a non-streaming chat completion request and
+// returns the trimmed text of the first choice. When auto-continue is enabled
+// and the answer was cut off by the token limit, the request is re-issued with
+// the partial answer and the parts are stitched together. A choice without
+// text fails with ErrEmptyContent unless it calls tools, in which case the
+// text is empty; use CreateChatCompletionFull or a ToolRunner to read the
+// calls.
 func (c *Client) CreateChatCompletion(
 	ctx context.Context,
 	req ChatCompletionRequest,
 ) (string, error) {
+	message, err := c.complete(ctx, req, "")
+	if err != nil {
+		return "", err
+	}
+
+	answer := strings.TrimSpace(message.Content)
+	if answer == "" {
+		if len(message.ToolCalls) > 0 {
+			return "", nil
+		}
+		return "", ErrEmptyContent
+	}
+	return c.postProcess.apply(req, answer), nil
+}
+
+// CreateChatCompletionFull is like CreateChatCompletion but returns the whole
+// response, with its ID, model, usage, and every choice. Content is neither
+// trimmed nor post-processed, and a refusal is returned in the message rather
+// than as an error. With auto-continue, the first choice holds the stitched
+// answer and the finish reason of the last part, Usage sums every request, and
+// Raw is the body of the first one.
+func (c *Client) CreateChatCompletionFull(
+	ctx context.Context,
+	req ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
+	return c.completeFull(ctx, req, "")
+}
+
+// complete runs completeFull and returns the message of the first choice,
+// holding only the new text
+func (c *Client) complete(
+	ctx context.Context,
+	base ChatCompletionRequest,
+	prefix string,
+) (Message, error) {
+	payload, err := c.completeFull(ctx, base, prefix)
+	if err != nil {
+		return Message{}, err
+	}
+	message := payload.Choices[0].Message
+	if message.Refusal != "" {
+		return Message{}, &RefusalError{Refusal: message.Refusal}
+	}
+	return message, nil
+}
+
+// completeFull runs a non-streaming completion, following length truncations
+// of the first choice when auto-continue is enabled. A non-empty prefix is
+// treated as an answer the model already started: the first request asks for
+// its continuation and the first choice holds only the new text.
+func (c *Client) completeFull(
+	ctx context.Context,
+	base ChatCompletionRequest,
+	prefix string,
+) (*ChatCompletionResponse, error) {
+	req := base
+	if prefix != "" {
+		req = continuationRequest(base, prefix)
+	}
+
+	var result *ChatCompletionResponse
+	answer := prefix
+	for attempt := 0; ; attempt++ {
+		payload, err := c.createChatCompletion(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(payload.Choices) == 0 {
+			return nil, ErrNoChoices
+		}
+
+		choice := payload.Choices[0]
+		if result == nil {
+			result = payload
+		} else {
+			result.Usage.PromptTokens += payload.Usage.PromptTokens
+			result.Usage.CompletionTokens += payload.Usage.CompletionTokens
+			result.Usage.TotalTokens += payload.Usage.TotalTokens
+			result.Choices[0].FinishReason = choice.FinishReason
+			result.Choices[0].Message.Refusal = choice.Message.Refusal
+		}
+		if choice.Message.Refusal != "" {
+			break
+		}
+		answer += trimOverlap(answer, choice.Message.Content)
+
+		if choice.FinishReason != finishReasonLength || attempt >= c.autoContinue {
+			break
+		}
+		req = continuationRequest(base, answer)
+	}
+
+	result.Choices[0].Message.Content = answer[len(prefix):]
+	return result, nil
+}
+
+// createChatCompletion sends a non-streaming request and decodes the full
+// response payload
+func (c *Client) createChatCompletion(
+	ctx context.Context,
+	req ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
 	req.Stream = false
+	req.StreamOptions = nil
+	if c.compat != nil {
+		req = c.compat.apply(req)
+	}
+	if c.redactor != nil {
+		req.Messages = c.redactor.Messages(req.Messages)
+	}
 
 	body, err := marshalRequest(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	resp, err := c.doRequest(ctx, "POST", "/chat/completions", body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var payload ChatCompletionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := c.decodeResponse(resp.Body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if len(payload.Choices) == 0 {
-		return "", fmt.Errorf("no completion choices returned")
+	c.recordUsage(req.Model, payload.Usage.PromptTokens, payload.Usage.CompletionTokens)
+
+	if c.stopEmulation {
+		for i := range payload.Choices {
+			choice := &payload.Choices[i]
+			if before, ok := cutAtStop(choice.Message.Content, req.Stop); ok {
+				choice.Message.Content = before
+				choice.FinishReason = finishReasonStop
+			}
+		}
 	}
 
-	return strings.TrimSpace(payload.Choices[0].Message.Content), nil
+	return &payload, nil
 }
 
 // CreateChatCompletionStream sends a streaming chat completion request
@@ -184,22 +629,62 @@ func (c *Client) CreateChatCompletionStream(
 	req ChatCompletionRequest,
 ) (*StreamReader, error) {
 	req.Stream = true
+	if c.compat != nil {
+		req = c.compat.apply(req)
+	}
+	if c.redactor != nil {
+		req.Messages = c.redactor.Messages(req.Messages)
+	}
 
 	body, err := marshalRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.doRequest(ctx, "POST", "/chat/completions", body)
+	correlationID := correlationIDFor(ctx)
+	ctx = WithCorrelationID(ctx, correlationID)
+
+	// The stream stays in flight for Close until its reader closes the body.
+	start := time.Now()
+	resp, err := c.doRequest(withStreaming(ctx), "POST", "/chat/completions", body)
 	if err != nil {
 		return nil, err
 	}
 
-	return &StreamReader{
-		reader:  bufio.NewReader(resp.Body),
-		closer:  resp.Body,
-		isFirst: true,
-	}, nil
+	stream := &StreamReader{
+		reader:   bufio.NewReader(resp.Body),
+		closer:   resp.Body,
+		isFirst:  true,
+		latency:  c.latency,
+		endpoint: "POST /chat/completions",
+		start:    start,
+
+		correlationID: correlationID,
+		strict:        c.strict,
+		strictFrames:  c.strictStreams,
+		rateLimit:     observedRateLimit(resp.Header),
+	}
+
+	if c.budget != nil {
+		stream.spend = newStreamSpend(c.budget, req)
+	}
+	if c.usage != nil {
+		stream.onUsage = c.usage.recordStream
+	}
+	if c.stopEmulation {
+		stream.stops = newStopWatcher(req.Stop, req.N)
+	}
+
+	if c.capture != nil {
+		file, err := c.capture.create()
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		stream.capture = &captureWriter{w: file, redact: c.capture.redact}
+	}
+
+	return stream, nil
 }
 
 // CreateChatCompletionStreamWithMarkdown sends a streaming chat completion request
@@ -210,11 +695,27 @@ func (c *Client) CreateChatCompletionStreamWithMarkdown(
 	w io.Writer,
 	opts StreamOptions,
 ) error {
+	return c.streamWithMarkdown(ctx, req, "", w, opts)
+}
+
+// streamWithMarkdown wires a chunk pump into the markdown renderer. prefix has
+// the same meaning as in complete.
+func (c *Client) streamWithMarkdown(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	prefix string,
+	w io.Writer,
+	opts StreamOptions,
+) error {
+	if err := markdown.ValidateOptions(opts); err != nil {
+		return err
+	}
+
 	readerCtx, cancelReader := context.WithCancel(ctx)
 	defer cancelReader()
 
 	closer := &deferredCloser{}
-	pump := c.startChunkPump(readerCtx, req, closer)
+	pump := c.startChunkPump(readerCtx, req, prefix, closer, opts.RegenerateTemperature)
 
 	userCancel := opts.Cancel
 	opts.Cancel = func() {
@@ -225,6 +726,21 @@ func (c *Client) CreateChatCompletionStreamWithMarkdown(
 		}
 	}
 
+	if userComplete := opts.OnComplete; userComplete != nil {
+		opts.OnComplete = func(stats StreamStats) {
+			stats.PromptTokens, stats.CompletionTokens = pump.usage.totals()
+			userComplete(stats)
+		}
+	}
+
+	userRegenerate := opts.Regenerate
+	opts.Regenerate = func() {
+		pump.requestRegenerate()
+		if userRegenerate != nil {
+			userRegenerate()
+		}
+	}
+
 	next := func(nextCtx context.Context) (markdown.Chunk, error) {
 		select {
 		case <-nextCtx.Done():
@@ -237,17 +753,20 @@ func (c *Client) CreateChatCompletionStreamWithMarkdown(
 		}
 	}
 
-	if opts.UIWriter == nil {
-		if file, ok := w.(*os.File); ok && file == os.Stdout {
-			opts.UIWriter = os.Stderr
-		}
-	}
-
 	uiErr := markdown.StreamMarkdown(ctx, next, w, opts)
+
+	// The renderer may finish before the stream does, for example after
+	// reaching an output limit; stop the producer so its error reflects that.
+	cancelReader()
+	closer.Close()
 	pumpErr := <-pump.done
+	if uiErr == nil && ctx.Err() == nil && errors.Is(pumpErr, context.Canceled) {
+		pumpErr = nil
+	}
 
 	if uiErr != nil {
-		if errors.Is(uiErr, context.Canceled) && pumpErr != nil {
+		var interrupted *markdown.InterruptedError
+		if errors.Is(uiErr, context.Canceled) && pumpErr != nil && !errors.As(uiErr, &interrupted) {
 			return pumpErr
 		}
 		return uiErr
@@ -259,69 +778,240 @@ func (c *Client) CreateChatCompletionStreamWithMarkdown(
 // chunkPump holds the channels used to pass chunks to the markdown renderer
 // and to report completion/error back to the caller.
 type chunkPump struct {
-	chunks <-chan markdown.Chunk
-	done   <-chan error
+	chunks     <-chan markdown.Chunk
+	done       <-chan error
+	regenerate chan<- struct{}
+	usage      *streamUsage
+}
+
+// streamUsage sums the usage reported by the streams of a pump
+type streamUsage struct {
+	mu                             sync.Mutex
+	promptTokens, completionTokens int
+}
+
+// add records the usage of one stream, if it reported any
+func (u *streamUsage) add(usage *Usage) {
+	if usage == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.promptTokens += usage.PromptTokens
+	u.completionTokens += usage.CompletionTokens
+}
+
+// totals returns the usage recorded so far
+func (u *streamUsage) totals() (promptTokens, completionTokens int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.promptTokens, u.completionTokens
+}
+
+// requestRegenerate asks the pump to abandon the current answer and start
+// over. Requests made while one is pending are dropped.
+func (p *chunkPump) requestRegenerate() {
+	select {
+	case p.regenerate <- struct{}{}:
+	default:
+	}
 }
 
 // startChunkPump spins up a goroutine that reads SSE events from OpenAI and
 // forwards only the streamed text into a channel suitable for the markdown
 // renderer. It ensures the underlying stream is closed exactly once and that
-// errors are propagated through the done channel.
+// errors are propagated through the done channel. A regeneration request
+// cancels the current stream, sends a reset chunk, and re-submits the request,
+// at temperature when it is positive.
 func (c *Client) startChunkPump(
 	ctx context.Context,
-	req ChatCompletionRequest,
+	base ChatCompletionRequest,
+	prefix string,
 	closer *deferredCloser,
+	temperature float32,
 ) *chunkPump {
 	chunkCh := make(chan markdown.Chunk)
 	doneCh := make(chan error, 1)
+	regenerateCh := make(chan struct{}, 1)
+	usage := &streamUsage{}
 
 	go func() {
 		defer close(chunkCh)
 		var finalErr error
 		defer func() { doneCh <- finalErr }()
+		defer closer.Close()
 
-		stream, err := c.CreateChatCompletionStream(ctx, req)
-		if err != nil {
-			finalErr = fmt.Errorf("failed to create stream: %w", err)
-			return
+		first := func() ChatCompletionRequest {
+			if prefix != "" {
+				return continuationRequest(base, prefix)
+			}
+			return base
 		}
+		req := first()
 
-		closer.Set(func() { stream.Close() })
-		defer closer.Close()
+		var answer strings.Builder
+		answer.WriteString(prefix)
 
-		for {
-			chunk, recvErr := stream.Recv()
-			if recvErr == io.EOF {
-				return
-			}
-			if recvErr != nil {
-				finalErr = fmt.Errorf("stream error: %w", recvErr)
-				return
+		for attempt := 0; ; attempt++ {
+			var seam *seamTrimmer
+			if answer.Len() > 0 {
+				seam = &seamTrimmer{prev: answer.String()}
 			}
 
-			text := extractDeltaText(chunk)
-			if text == "" {
+			streamCtx, regenerated := watchRegenerate(ctx, regenerateCh)
+			finishReason, err := c.pumpStream(streamCtx, req, closer, chunkCh, &answer, seam, usage)
+			if regenerated() && ctx.Err() == nil {
+				if temperature > 0 {
+					base.Temperature = &temperature
+				}
+				req = first()
+				answer.Reset()
+				answer.WriteString(prefix)
+				attempt = -1
+				select {
+				case chunkCh <- markdown.Chunk{Reset: true}:
+				case <-ctx.Done():
+					finalErr = ctx.Err()
+					return
+				}
 				continue
 			}
-
-			select {
-			case chunkCh <- markdown.Chunk{Text: text}:
-			case <-ctx.Done():
-				finalErr = ctx.Err()
+			if err != nil {
+				finalErr = err
+				return
+			}
+			if finishReason != finishReasonLength || attempt >= c.autoContinue {
 				return
 			}
+			req = continuationRequest(base, answer.String())
 		}
 	}()
 
 	return &chunkPump{
-		chunks: chunkCh,
-		done:   doneCh,
+		chunks:     chunkCh,
+		done:       doneCh,
+		regenerate: regenerateCh,
+		usage:      usage,
+	}
+}
+
+// watchRegenerate returns a context that is canceled when a regeneration is
+// requested, and a function that releases it and reports whether that
+// happened.
+func watchRegenerate(ctx context.Context, regenerate <-chan struct{}) (context.Context, func() bool) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	fired := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-regenerate:
+			fired = true
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+	return streamCtx, func() bool {
+		cancel()
+		<-done
+		return fired
+	}
+}
+
+// pumpStream forwards the text of a single stream into chunkCh, appending it
+// to answer, and reports the finish reason of the first choice. When seam is
+// set, text repeating the end of the previous answer is dropped. Reported
+// usage is added to usage.
+func (c *Client) pumpStream(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	closer *deferredCloser,
+	chunkCh chan<- markdown.Chunk,
+	answer *strings.Builder,
+	seam *seamTrimmer,
+	usage *streamUsage,
+) (string, error) {
+	stream, err := c.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create stream: %w", err)
+	}
+
+	closer.Set(func() { stream.Close() })
+	defer stream.Close()
+	defer func() { usage.add(stream.Usage()) }()
+
+	stream.onHeartbeat = func() {
+		select {
+		case chunkCh <- markdown.Chunk{Heartbeat: true}:
+		case <-ctx.Done():
+		}
+	}
+
+	send := func(text string) error {
+		if text == "" {
+			return nil
+		}
+		answer.WriteString(text)
+		select {
+		case chunkCh <- markdown.Chunk{Text: text}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var finishReason string
+	for {
+		chunk, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			if seam != nil {
+				if err := send(seam.flush()); err != nil {
+					return "", err
+				}
+			}
+			return finishReason, nil
+		}
+		if recvErr != nil {
+			return "", fmt.Errorf("stream error: %w", recvErr)
+		}
+
+		if reason := extractFinishReason(chunk); reason != "" {
+			finishReason = reason
+		}
+
+		text := extractDeltaText(chunk)
+		if seam != nil {
+			text = seam.feed(text)
+		}
+		if err := send(text); err != nil {
+			return "", err
+		}
 	}
 }
 
+func extractFinishReason(resp ChatCompletionStreamResponse) string {
+	choice := firstChoice(resp)
+	if choice == nil || choice.FinishReason == nil {
+		return ""
+	}
+	return *choice.FinishReason
+}
+
 func extractDeltaText(resp ChatCompletionStreamResponse) string {
-	if len(resp.Choices) == 0 {
+	choice := firstChoice(resp)
+	if choice == nil {
 		return ""
 	}
-	return resp.Choices[0].Delta.Content
+	return choice.Delta.Content
+}
+
+// firstChoice returns the part of a chunk for choice 0, which need not come
+// first when N > 1
+func firstChoice(resp ChatCompletionStreamResponse) *StreamChoice {
+	for i := range resp.Choices {
+		if resp.Choices[i].Index == 0 {
+			return &resp.Choices[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// FuzzRecv feeds arbitrary bytes through the stream parser, which must never
+// panic and must return either a chunk or an error for every call
+func FuzzRecv(f *testing.F) {
+	seeds := []string{
+		"data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n",
+		"data:{\"choices\":[]}\n",
+		": keep-alive\n\ndata: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}",
+		"data: {\"choices\":[{\"delta\":{\"content\":\n\ndata: {\"choices\":[]}\n",
+		"event: message\ndata: not json\n\n",
+		"data: [DONE]",
+		"data: {\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":2,\"total_tokens\":3}}\n",
+		"data:\n\n\r\n",
+		"",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed), false)
+		f.Add([]byte(seed), true)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, strictFrames bool) {
+		body := io.NopCloser(bytes.NewReader(data))
+		stream := &StreamReader{
+			reader:       bufio.NewReader(body),
+			closer:       body,
+			isFirst:      true,
+			strictFrames: strictFrames,
+		}
+		defer stream.Close()
+
+		// Every call consumes at least one line, so the stream ends within
+		// one call per byte.
+		for range len(data) + 2 {
+			_, err := stream.Recv()
+			if err == nil {
+				continue
+			}
+			if err == io.EOF {
+				return
+			}
+			var parseErr *StreamParseError
+			if !errors.As(err, &parseErr) {
+				// Only malformed frames are reported for in-memory input.
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strictFrames {
+				t.Fatalf("lenient stream returned %v", err)
+			}
+			if parseErr.Offset < 0 || parseErr.Offset > int64(len(data)) {
+				t.Fatalf("offset %d outside input of %d bytes", parseErr.Offset, len(data))
+			}
+			if len(parseErr.Data) > maxParseErrorBytes {
+				t.Fatalf("kept %d bytes of frame", len(parseErr.Data))
+			}
+			// Strict streams may be read past a bad frame.
+		}
+		t.Fatalf("stream did not end within %d calls", len(data)+2)
+	})
+}
@@ -0,0 +1,18 @@
+package openai
+
+import (
+	"context"
+	"io"
+)
+
+// ChatClient is the chat completion surface of Client. Code that depends on it
+// instead of *Client can be tested with openaitest.Fake or a custom double;
+// NewStreamReader builds streams from synthetic SSE bytes.
+type ChatClient interface {
+	CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (string, error)
+	CreateChatCompletionFull(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, req ChatCompletionRequest) (*StreamReader, error)
+	CreateChatCompletionStreamWithMarkdown(ctx context.Context, req ChatCompletionRequest, w io.Writer, opts StreamOptions) error
+}
+
+var _ ChatClient = (*Client)(nil)
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"io"
+	"slices"
+)
+
+// CreateChatCompletions sends a non-streaming request and returns every
+// choice, for requests with N > 1. Content is returned as sent. Auto-continue
+// does not apply, since each choice would need its own continuation.
+func (c *Client) CreateChatCompletions(ctx context.Context, req ChatCompletionRequest) ([]Choice, error) {
+	payload, err := c.createChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload.Choices) == 0 {
+		return nil, ErrNoChoices
+	}
+	return payload.Choices, nil
+}
+
+// Demux reads the rest of the stream, calling fn, when non-nil, with the
+// part of every chunk that belongs to each choice, so the choices of an N > 1
+// request can be routed by StreamChoice.Index. It returns the choices
+// assembled from the deltas, ordered by index, including their tool calls and
+// audio.
+// An error from fn stops reading and is returned; the stream is not closed.
+func (s *StreamReader) Demux(fn func(StreamChoice) error) ([]Choice, error) {
+	type assembly struct {
+		choice Choice
+		tools  ToolCallAccumulator
+		audio  *MessageAudio
+		data   bytes.Buffer
+	}
+	byIndex := make(map[int]*assembly)
+
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, delta := range chunk.Choices {
+			if fn != nil {
+				if err := fn(delta); err != nil {
+					return nil, err
+				}
+			}
+			a, ok := byIndex[delta.Index]
+			if !ok {
+				a = &assembly{choice: Choice{Index: delta.Index}, tools: ToolCallAccumulator{Choice: delta.Index}}
+				byIndex[delta.Index] = a
+			}
+			if delta.Delta.Role != "" {
+				a.choice.Message.Role = delta.Delta.Role
+			}
+			a.choice.Message.Content += delta.Delta.Content
+			if delta.FinishReason != nil {
+				a.choice.FinishReason = *delta.FinishReason
+			}
+			if delta.Delta.Audio != nil {
+				if a.audio == nil {
+					a.audio = &MessageAudio{}
+				}
+				if err := a.audio.append(delta.Delta.Audio, &a.data); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, a := range byIndex {
+			a.tools.Add(chunk)
+		}
+	}
+
+	choices := make([]Choice, 0, len(byIndex))
+	for _, a := range byIndex {
+		a.choice.Message.ToolCalls = a.tools.ToolCalls()
+		if a.audio != nil {
+			a.audio.Data = base64.StdEncoding.EncodeToString(a.data.Bytes())
+			a.choice.Message.Audio = a.audio
+		}
+		choices = append(choices, a.choice)
+	}
+	slices.SortFunc(choices, func(a, b Choice) int { return cmp.Compare(a.Index, b.Index) })
+	return choices, nil
+}
@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClientClosed is returned by calls made after Client.Close.
+var ErrClientClosed = errors.New("client closed")
+
+// Flusher is implemented by latency exporters and other observers that buffer
+// data and should be flushed when the client closes.
+type Flusher interface {
+	Flush() error
+}
+
+// lifecycle tracks calls in flight so Close can wait for them
+type lifecycle struct {
+	mu      sync.Mutex
+	closed  bool
+	active  int
+	drained chan struct{}
+}
+
+// begin registers a call, failing once the client is closed
+func (l *lifecycle) begin() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClientClosed
+	}
+	l.active++
+	return nil
+}
+
+// end unregisters a call
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.closed && l.active == 0 {
+		close(l.drained)
+	}
+}
+
+// close stops new calls and returns a channel closed once none are in flight
+func (l *lifecycle) close() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		l.drained = make(chan struct{})
+		if l.active == 0 {
+			close(l.drained)
+		}
+	}
+	return l.drained
+}
+
+// Close shuts the client down gracefully: new calls fail with ErrClientClosed,
+// calls and streams in flight are waited for until their response bodies are
+// closed or ctx is done, idle connections are closed, and every observer that
+// buffers data is flushed: the budget tracker records the estimated spend of
+// streams still open, and a latency exporter, audit sink, or logger handler
+// implementing Flusher is flushed. Close returns ctx's error if calls were
+// still in flight when it expired; they keep running until their bodies are
+// closed.
+func (c *Client) Close(ctx context.Context) error {
+	var errs []error
+	select {
+	case <-c.lifecycle.close():
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("calls still in flight: %w", ctx.Err()))
+	}
+
+	c.closeIdleConnections()
+
+	for _, f := range c.flushers() {
+		if err := f.flusher.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %s: %w", f.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeIdleConnections closes the pooled connections of both HTTP clients
+func (c *Client) closeIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+	c.streamClient.CloseIdleConnections()
+}
+
+// namedFlusher is an observer flushed by Close
+type namedFlusher struct {
+	name    string
+	flusher Flusher
+}
+
+// flushers returns the client's observers that buffer data
+func (c *Client) flushers() []namedFlusher {
+	var flushers []namedFlusher
+	if c.budget != nil {
+		flushers = append(flushers, namedFlusher{"budget tracker", c.budget})
+	}
+	if f, ok := c.latency.(Flusher); ok {
+		flushers = append(flushers, namedFlusher{"latency exporter", f})
+	}
+	if c.audit != nil {
+		if f, ok := c.audit.sink.(Flusher); ok {
+			flushers = append(flushers, namedFlusher{"audit sink", f})
+		}
+	}
+	if c.logger != nil {
+		if f, ok := c.logger.Handler().(Flusher); ok {
+			flushers = append(flushers, namedFlusher{"log handler", f})
+		}
+	}
+	return flushers
+}
@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+)
+
+// schemaNameInvalid matches the characters not allowed in schema names
+var schemaNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// CompleteInto asks for an answer matching the schema SchemaOf derives from T
+// through strict structured outputs and decodes it into T. Types other than
+// structs are wrapped in an object, since the schema root must be one. An
+// answer that is not valid JSON for T is retried once with the decoding
+// error; the *ValidationError of a second failure holds both answers.
+func CompleteInto[T any](ctx context.Context, c *Client, req ChatCompletionRequest) (T, error) {
+	var result T
+	t := reflect.TypeFor[T]()
+	schema, err := SchemaOf[T]()
+	if err != nil {
+		return result, err
+	}
+	wrapped := t.Kind() != reflect.Struct
+	if wrapped {
+		no := false
+		schema = &JSONSchema{
+			Type:                 "object",
+			Properties:           map[string]*JSONSchema{"value": schema},
+			Required:             []string{"value"},
+			AdditionalProperties: &no,
+		}
+	}
+
+	name := schemaNameInvalid.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		name = "response"
+	}
+	req.ResponseFormat = JSONSchemaResponseFormat(name, schema, true)
+
+	_, _, err = c.CreateValidatedCompletion(ctx, req, 1, func(content string) error {
+		var decoded T
+		if wrapped {
+			var envelope struct {
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal([]byte(content), &envelope); err != nil {
+				return err
+			}
+			if envelope.Value == nil {
+				return errors.New("missing value")
+			}
+			if err := json.Unmarshal(envelope.Value, &decoded); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal([]byte(content), &decoded); err != nil {
+			return err
+		}
+		result = decoded
+		return nil
+	})
+	return result, err
+}
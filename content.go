@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContentPartType identifies the kind of a content part
+type ContentPartType string
+
+const (
+	// ContentPartText is a plain text content part
+	ContentPartText ContentPartType = "text"
+	// ContentPartImageURL is an image given by URL or data URL, for vision
+	// models
+	ContentPartImageURL ContentPartType = "image_url"
+)
+
+// Image detail levels; low is cheaper, high reads fine print
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// ImageURL is the image of an image_url content part
+type ImageURL struct {
+	// URL is an https URL or a base64 data URL
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CacheControl carries prompt caching hints for gateways that support them
+type CacheControl struct {
+	Type string `json:"type"`
+}
+
+// ContentPart is a single typed segment of a message's content
+type ContentPart struct {
+	Type         ContentPartType `json:"type"`
+	Text         string          `json:"text,omitempty"`
+	ImageURL     *ImageURL       `json:"image_url,omitempty"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
+}
+
+// TextPart builds a text content part
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImagePart builds an image content part from a URL; detail may be empty
+func ImagePart(url, detail string) ContentPart {
+	return ContentPart{Type: ContentPartImageURL, ImageURL: &ImageURL{URL: url, Detail: detail}}
+}
+
+// ImageDataPart builds an image content part embedding data as a data URL,
+// for local files; mimeType is such as "image/png"
+func ImageDataPart(mimeType string, data []byte, detail string) ContentPart {
+	return ImagePart("data:"+mimeType+";base64,"+base64.StdEncoding.EncodeToString(data), detail)
+}
+
+// MessageContent is the content of a message. It marshals as a plain JSON
+// string when it consists of a single text part without extensions and as an
+// array of parts otherwise.
+type MessageContent []ContentPart
+
+// Text concatenates the text of every text part
+func (c MessageContent) Text() string {
+	var b strings.Builder
+	for _, part := range c {
+		if part.Type == ContentPartText {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+// isPlainText reports whether the content can be represented as a bare string
+func (c MessageContent) isPlainText() bool {
+	if len(c) == 0 {
+		return true
+	}
+	return len(c) == 1 && c[0].Type == ContentPartText && c[0].CacheControl == nil
+}
+
+// MarshalJSON encodes the content as a string or an array of parts
+func (c MessageContent) MarshalJSON() ([]byte, error) {
+	if c.isPlainText() {
+		return json.Marshal(c.Text())
+	}
+	return json.Marshal([]ContentPart(c))
+}
+
+// UnmarshalJSON accepts a string, an array of parts, or null
+func (c *MessageContent) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case trimmed == "null":
+		*c = nil
+		return nil
+	case strings.HasPrefix(trimmed, `"`):
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = MessageContent{TextPart(text)}
+		return nil
+	case strings.HasPrefix(trimmed, "["):
+		var parts []ContentPart
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return err
+		}
+		*c = parts
+		return nil
+	default:
+		return fmt.Errorf("invalid message content: %s", trimmed)
+	}
+}
+
+// MarshalJSON encodes the message, sending Parts in place of Content when set
+// and only the ID of Audio
+func (m Message) MarshalJSON() ([]byte, error) {
+	type alias Message
+	content := m.Parts
+	if len(content) == 0 {
+		content = MessageContent{TextPart(m.Content)}
+	}
+	if m.Audio != nil {
+		m.Audio = &MessageAudio{ID: m.Audio.ID}
+	}
+	return json.Marshal(struct {
+		alias
+		Content MessageContent `json:"content"`
+	}{alias(m), content})
+}
+
+// UnmarshalJSON decodes the message, filling Content with the text of the
+// content and Parts when the content was sent as an array
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type alias Message
+	aux := struct {
+		*alias
+		Content MessageContent `json:"content"`
+	}{alias: (*alias)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	m.Content = aux.Content.Text()
+	m.Parts = nil
+	if !aux.Content.isPlainText() {
+		m.Parts = aux.Content
+	}
+	return nil
+}
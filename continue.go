@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// finishReasonLength marks an answer cut off by the output token limit
+const finishReasonLength = "length"
+
+// continuePrompt asks the model to resume a truncated answer
+const continuePrompt = "Continue exactly where you left off. Do not repeat any text you already wrote."
+
+const (
+	// minSeamOverlap is the shortest repeated text treated as an overlap, so
+	// that coincidental single-character matches are kept
+	minSeamOverlap = 8
+	// maxSeamOverlap bounds how far back the seam between parts is searched
+	maxSeamOverlap = 256
+)
+
+// continuationRequest builds a follow-up request that feeds the partial answer
+// back as an assistant message followed by an instruction to continue
+func continuationRequest(req ChatCompletionRequest, partial string) ChatCompletionRequest {
+	return appendTurn(req, partial, continuePrompt)
+}
+
+// appendTurn returns req with an assistant message and a user reply appended.
+// The messages of req are copied so the caller's slice is never modified.
+func appendTurn(req ChatCompletionRequest, assistant, user string) ChatCompletionRequest {
+	messages := make([]Message, 0, len(req.Messages)+2)
+	messages = append(messages, req.Messages...)
+	messages = append(messages, AssistantMessage(assistant), UserMessage(user))
+	req.Messages = messages
+	return req
+}
+
+// ContinueCompletion asks the model to continue the last assistant message of
+// conversation and returns only the newly generated tail. Text at the start of
+// the continuation that repeats the end of the existing answer is removed.
+func (c *Client) ContinueCompletion(
+	ctx context.Context,
+	conversation ChatCompletionRequest,
+) (string, error) {
+	base, partial, err := splitPartialAnswer(conversation)
+	if err != nil {
+		return "", err
+	}
+	message, err := c.complete(ctx, base, partial)
+	if err != nil {
+		return "", err
+	}
+	tail := message.Content
+	if strings.TrimSpace(tail) == "" {
+		if len(message.ToolCalls) > 0 {
+			return "", nil
+		}
+		return "", ErrEmptyContent
+	}
+	return c.postProcess.apply(base, tail), nil
+}
+
+// ContinueCompletionStreamWithMarkdown streams the continuation of the last
+// assistant message of conversation, rendering only the new tail.
+func (c *Client) ContinueCompletionStreamWithMarkdown(
+	ctx context.Context,
+	conversation ChatCompletionRequest,
+	w io.Writer,
+	opts StreamOptions,
+) error {
+	base, partial, err := splitPartialAnswer(conversation)
+	if err != nil {
+		return err
+	}
+	return c.streamWithMarkdown(ctx, base, partial, w, opts)
+}
+
+// splitPartialAnswer separates the trailing assistant message from the rest of
+// the conversation
+func splitPartialAnswer(conversation ChatCompletionRequest) (ChatCompletionRequest, string, error) {
+	n := len(conversation.Messages)
+	if n == 0 || conversation.Messages[n-1].Role != RoleAssistant {
+		return conversation, "", fmt.Errorf("conversation must end with an assistant message")
+	}
+
+	last := conversation.Messages[n-1]
+	partial := last.Content
+	if len(last.Parts) > 0 {
+		partial = last.Parts.Text()
+	}
+	if partial == "" {
+		return conversation, "", fmt.Errorf("last assistant message has no content to continue")
+	}
+
+	conversation.Messages = conversation.Messages[:n-1]
+	return conversation, partial, nil
+}
+
+// trimOverlap removes the longest prefix of next that repeats the end of prev
+func trimOverlap(prev, next string) string {
+	limit := min(len(prev), len(next), maxSeamOverlap)
+	for k := limit; k >= minSeamOverlap; k-- {
+		if k < len(next) && !utf8.RuneStart(next[k]) {
+			continue
+		}
+		if strings.HasSuffix(prev, next[:k]) {
+			return next[k:]
+		}
+	}
+	return next
+}
+
+// seamTrimmer applies trimOverlap to a streamed continuation by holding back
+// the first maxSeamOverlap bytes until the overlap can be decided
+type seamTrimmer struct {
+	prev string
+	buf  strings.Builder
+	done bool
+}
+
+// feed buffers text until enough has arrived to resolve the seam, then returns
+// the trimmed text; afterwards text passes through unchanged
+func (t *seamTrimmer) feed(text string) string {
+	if t.done {
+		return text
+	}
+	t.buf.WriteString(text)
+	if t.buf.Len() < maxSeamOverlap {
+		return ""
+	}
+	return t.flush()
+}
+
+// flush resolves the seam with whatever has been buffered
+func (t *seamTrimmer) flush() string {
+	if t.done {
+		return ""
+	}
+	t.done = true
+	return trimOverlap(t.prev, t.buf.String())
+}
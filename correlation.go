@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// CorrelationHeader carries the client-generated correlation ID of a call.
+// OpenAI echoes it in its own logs, so it links client and server records.
+const CorrelationHeader = "X-Client-Request-Id"
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context whose calls use id as their correlation
+// ID instead of a generated one, so an existing trace or request ID can be
+// propagated
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// correlationIDFor returns the caller-supplied correlation ID of ctx or
+// generates a new one
+func correlationIDFor(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationKey{}).(string); ok && id != "" {
+		return id
+	}
+	return newCorrelationID()
+}
+
+// newCorrelationID returns a random 128-bit hex identifier
+func newCorrelationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// correlatedError attaches the correlation ID of a call to its error
+type correlatedError struct {
+	id  string
+	err error
+}
+
+func (e *correlatedError) Error() string {
+	return fmt.Sprintf("%s [correlation_id=%s]", e.err, e.id)
+}
+
+func (e *correlatedError) Unwrap() error {
+	return e.err
+}
+
+// withCorrelation wraps err with id unless it is nil or already correlated
+func withCorrelation(id string, err error) error {
+	if err == nil || id == "" {
+		return err
+	}
+	var existing *correlatedError
+	if errors.As(err, &existing) {
+		return err
+	}
+	return &correlatedError{id: id, err: err}
+}
+
+// CorrelationID returns the correlation ID attached to err, or "" when err did
+// not originate from a call made by this package
+func CorrelationID(err error) string {
+	var correlated *correlatedError
+	if errors.As(err, &correlated) {
+		return correlated.id
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errStopList is returned by a decodeList callback to stop decoding early
+var errStopList = errors.New("stop list decoding")
+
+// decodeList decodes a JSON object from r incrementally, passing each element
+// of its "data" array to yield as soon as it is parsed, so memory is bounded
+// by the largest element rather than the whole response. The other top-level
+// fields are decoded into meta when it is non-nil. An error from yield stops
+// decoding and is returned without reading the rest of r.
+func decodeList[T any](r io.Reader, strict bool, meta any, yield func(T) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	rest := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if key != "data" {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			rest[key] = raw
+			continue
+		}
+		if err := decodeListItems(dec, strict, yield); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+
+	if meta == nil {
+		return nil
+	}
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, meta, strict)
+}
+
+// decodeListItems decodes the "data" array, which may be null
+func decodeListItems[T any](dec *json.Decoder, strict bool, yield func(T) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected data array, got %v", tok)
+	}
+
+	for dec.More() {
+		var item T
+		if strict {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			if err := decodeJSON(raw, &item, true); err != nil {
+				return err
+			}
+		} else if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := yield(item); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if got, ok := tok.(json.Delim); !ok || got != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
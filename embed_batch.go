@@ -0,0 +1,263 @@
+package openai
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// Defaults of EmbedBatchOptions, matching the limits of the embeddings
+// endpoint
+const (
+	defaultEmbedBatchInputs      = 2048
+	defaultEmbedBatchTokens      = 300_000
+	defaultEmbedBatchConcurrency = 4
+	defaultEmbedRateLimitRetries = 3
+)
+
+// EmbedBatchOptions configures EmbedBatch. Zero fields take their defaults.
+type EmbedBatchOptions struct {
+	// MaxInputs bounds the texts of one request; it defaults to 2048
+	MaxInputs int
+	// MaxTokens bounds the estimated tokens of one request; it defaults to
+	// 300000. A text above it is sent alone.
+	MaxTokens int
+	// Concurrency bounds the requests in flight; it defaults to 4
+	Concurrency int
+	// RateLimitRetries bounds how often a batch is resent after a
+	// *RateLimitError outlasting the client's own retries; it defaults to 3
+	RateLimitRetries int
+}
+
+// validate reports options out of range
+func (o EmbedBatchOptions) validate() error {
+	if o.MaxInputs < 0 {
+		return fmt.Errorf("invalid embedding batch size %d", o.MaxInputs)
+	}
+	if o.MaxTokens < 0 {
+		return fmt.Errorf("invalid embedding batch token limit %d", o.MaxTokens)
+	}
+	if o.Concurrency < 0 {
+		return fmt.Errorf("invalid embedding batch concurrency %d", o.Concurrency)
+	}
+	return nil
+}
+
+// EmbedBatchResult holds the vectors of an EmbedBatch call
+type EmbedBatchResult struct {
+	// Embeddings holds the vector of every input in input order; entries of
+	// failed batches are nil
+	Embeddings [][]float32
+	// Usage sums the usage of the successful batches
+	Usage EmbeddingUsage
+}
+
+// EmbedBatchFailure is a batch of an EmbedBatch call that failed
+type EmbedBatchFailure struct {
+	// Start and End delimit the inputs of the batch, End exclusive
+	Start, End int
+	Err        error
+}
+
+// EmbedBatchError reports the batches of an EmbedBatch call that failed,
+// ordered by Start; the other inputs have their vectors
+type EmbedBatchError struct {
+	Failures []EmbedBatchFailure
+}
+
+// Error implements the error interface
+func (e *EmbedBatchError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = fmt.Sprintf("inputs %d-%d: %v", f.Start, f.End-1, f.Err)
+	}
+	return fmt.Sprintf("%d embedding batches failed: %s", len(e.Failures), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the errors of the failed batches
+func (e *EmbedBatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// EmbedBatch embeds any number of texts, splitting req.Input into batches
+// bounded by count and estimated tokens and sending them concurrently. Before
+// a batch is sent, the client's latest rate limit state is consulted, and a
+// *RateLimitError pauses every worker for the requested delay before the
+// batch is resent. Vectors are returned in input order. When batches fail,
+// the result still holds the vectors of the others and the error is an
+// *EmbedBatchError.
+func (c *Client) EmbedBatch(ctx context.Context, req EmbeddingRequest, opts EmbedBatchOptions) (*EmbedBatchResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	concurrency := cmp.Or(opts.Concurrency, defaultEmbedBatchConcurrency)
+	retries := cmp.Or(opts.RateLimitRetries, defaultEmbedRateLimitRetries)
+
+	result := &EmbedBatchResult{Embeddings: make([][]float32, len(req.Input))}
+	batches := splitEmbedBatches(req.Input, cmp.Or(opts.MaxInputs, defaultEmbedBatchInputs), cmp.Or(opts.MaxTokens, defaultEmbedBatchTokens))
+
+	var (
+		mu       sync.Mutex
+		failures = make([]*EmbedBatchFailure, len(batches))
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		gate     embedGate
+	)
+	for i, b := range batches {
+		wg.Go(func() {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				failures[i] = &EmbedBatchFailure{Start: b.start, End: b.end, Err: ctx.Err()}
+				return
+			}
+
+			batchReq := req
+			batchReq.Input = req.Input[b.start:b.end]
+			resp, err := c.embedWithRetry(ctx, batchReq, i, b.tokens, retries, &gate)
+			if err == nil {
+				err = placeEmbeddings(result.Embeddings[b.start:b.end], resp.Data)
+			}
+			if err != nil {
+				failures[i] = &EmbedBatchFailure{Start: b.start, End: b.end, Err: err}
+				clear(result.Embeddings[b.start:b.end])
+				return
+			}
+			mu.Lock()
+			result.Usage.PromptTokens += resp.Usage.PromptTokens
+			result.Usage.TotalTokens += resp.Usage.TotalTokens
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	var batchErr EmbedBatchError
+	for _, f := range failures {
+		if f != nil {
+			batchErr.Failures = append(batchErr.Failures, *f)
+		}
+	}
+	if len(batchErr.Failures) > 0 {
+		return result, &batchErr
+	}
+	return result, nil
+}
+
+// embedWithRetry sends the batch at index, waiting for the gate and the
+// client's rate limit state first and resending it after rate limit errors
+// under the same idempotency key
+func (c *Client) embedWithRetry(
+	ctx context.Context,
+	req EmbeddingRequest,
+	index, tokens, retries int,
+	gate *embedGate,
+) (*EmbeddingResponse, error) {
+	scope := fmt.Sprintf("batch-%d", index)
+	for attempt := 0; ; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return nil, err
+		}
+		if info, ok := c.RateLimit(); ok {
+			// The resets count from when the state was observed.
+			now := time.Now()
+			var delay time.Duration
+			if info.RemainingRequests == 0 {
+				delay = info.remainingReset(info.ResetRequests, now)
+			}
+			if info.RemainingTokens >= 0 && info.RemainingTokens < tokens {
+				delay = max(delay, info.remainingReset(info.ResetTokens, now))
+			}
+			if delay > 0 {
+				if err := sleepContext(ctx, delay); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		resp, err := c.CreateEmbeddings(withIdempotencyScope(ctx, scope), req)
+		var rlErr *RateLimitError
+		if err == nil || !errors.As(err, &rlErr) || attempt >= retries {
+			return resp, err
+		}
+		gate.pause(cmp.Or(rlErr.Wait(), time.Second))
+	}
+}
+
+// placeEmbeddings stores the vectors of data in dst by their index
+func placeEmbeddings(dst [][]float32, data []Embedding) error {
+	for _, e := range data {
+		if e.Index < 0 || e.Index >= len(dst) {
+			return fmt.Errorf("embedding index %d out of range for %d inputs", e.Index, len(dst))
+		}
+		dst[e.Index] = e.Embedding
+	}
+	for i, v := range dst {
+		if v == nil {
+			return fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return nil
+}
+
+// embedBatch is the range of inputs of one request and its estimated tokens
+type embedBatch struct {
+	start, end int
+	tokens     int
+}
+
+// splitEmbedBatches splits input into consecutive batches of at most
+// maxInputs texts and maxTokens estimated tokens
+func splitEmbedBatches(input []string, maxInputs, maxTokens int) []embedBatch {
+	var batches []embedBatch
+	current := embedBatch{}
+	for i, text := range input {
+		tokens := token.Count(text)
+		if current.end > current.start && (current.end-current.start >= maxInputs || current.tokens+tokens > maxTokens) {
+			batches = append(batches, current)
+			current = embedBatch{start: i, end: i}
+		}
+		current.end = i + 1
+		current.tokens += tokens
+	}
+	if current.end > current.start {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// embedGate pauses the workers of an EmbedBatch call after a rate limit error
+type embedGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// pause holds back requests for d, extending an earlier pause
+func (g *embedGate) pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+// wait blocks until the gate is open or ctx is done
+func (g *embedGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	delay := time.Until(g.until)
+	g.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, delay)
+}
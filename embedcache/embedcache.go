@@ -0,0 +1,274 @@
+// Package embedcache stores embeddings on disk so that re-embedding unchanged
+// documents costs nothing.
+//
+// Entries are keyed by a hash of the model and the input text and kept in a
+// bbolt database. They expire after the configured TTL, and the oldest entries
+// are evicted once the cache holds more than MaxEntries vectors.
+package embedcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jiyeol-lee/openai"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// entriesBucket maps key hashes to a timestamp and the vector
+	entriesBucket = []byte("entries")
+	// ageBucket indexes entries by insertion time for eviction
+	ageBucket = []byte("age")
+	// metaBucket holds the entry count under countKey
+	metaBucket = []byte("meta")
+	countKey   = []byte("count")
+)
+
+// Options configures a Cache
+type Options struct {
+	// TTL expires entries this long after they were stored. Zero keeps them
+	// until evicted.
+	TTL time.Duration
+	// MaxEntries bounds the number of stored vectors, evicting the oldest
+	// first. Zero means no bound.
+	MaxEntries int
+}
+
+// Cache is a persistent embedding cache. It is safe for concurrent use.
+type Cache struct {
+	db   *bolt.DB
+	opts Options
+}
+
+// Open opens or creates the cache database at path
+func Open(path string, opts Options) (*Cache, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding cache: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{entriesBucket, ageBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize embedding cache: %w", err)
+	}
+	return &Cache{db: db, opts: opts}, nil
+}
+
+// Close closes the database
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Get returns the cached vector for input embedded with model
+func (c *Cache) Get(model, input string) ([]float32, bool) {
+	var vec []float32
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		vec = c.lookup(tx, key(model, input), time.Now())
+		return nil
+	})
+	return vec, vec != nil
+}
+
+// Put stores the vector for input embedded with model
+func (c *Cache) Put(model, input string, vec []float32) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return c.store(tx, key(model, input), vec, time.Now())
+	})
+}
+
+// Embed returns an embedding for every input of req, in input order. Cached
+// inputs are served from disk and only the rest are sent to the API, in a
+// single request whose results are then cached.
+func (c *Cache) Embed(ctx context.Context, client *openai.Client, req openai.EmbeddingRequest) ([][]float32, error) {
+	model := cacheModel(req)
+	vectors := make([][]float32, len(req.Input))
+
+	var missing []int
+	now := time.Now()
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		for i, input := range req.Input {
+			if vectors[i] = c.lookup(tx, key(model, input), now); vectors[i] == nil {
+				missing = append(missing, i)
+			}
+		}
+		return nil
+	})
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	miss := req
+	miss.Input = make([]string, len(missing))
+	for j, i := range missing {
+		miss.Input[j] = req.Input[i]
+	}
+	resp, err := client.CreateEmbeddings(ctx, miss)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(missing) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d inputs", len(resp.Data), len(missing))
+	}
+
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		for _, item := range resp.Data {
+			if item.Index < 0 || item.Index >= len(missing) {
+				return errors.New("embedding response index out of range")
+			}
+			i := missing[item.Index]
+			vectors[i] = item.Embedding
+			if err := c.store(tx, key(model, req.Input[i]), item.Embedding, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update embedding cache: %w", err)
+	}
+	return vectors, nil
+}
+
+// Prune deletes expired entries
+func (c *Cache) Prune() error {
+	if c.opts.TTL <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-c.opts.TTL)
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return c.evict(tx, func(stored time.Time, _ int) bool { return stored.Before(cutoff) })
+	})
+}
+
+// lookup returns the unexpired vector stored under k, or nil
+func (c *Cache) lookup(tx *bolt.Tx, k []byte, now time.Time) []float32 {
+	value := tx.Bucket(entriesBucket).Get(k)
+	if len(value) < 8 {
+		return nil
+	}
+	stored := time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+	if c.opts.TTL > 0 && now.Sub(stored) > c.opts.TTL {
+		return nil
+	}
+	return decodeVector(value[8:])
+}
+
+// store writes vec under k and evicts the oldest entries beyond MaxEntries
+func (c *Cache) store(tx *bolt.Tx, k []byte, vec []float32, now time.Time) error {
+	entries, age := tx.Bucket(entriesBucket), tx.Bucket(ageBucket)
+
+	count := entryCount(tx)
+	if old := entries.Get(k); len(old) >= 8 {
+		if err := age.Delete(append(old[:8:8], k...)); err != nil {
+			return err
+		}
+	} else {
+		count++
+	}
+
+	value := make([]byte, 8, 8+4*len(vec))
+	binary.BigEndian.PutUint64(value, uint64(now.UnixNano()))
+	value = appendVector(value, vec)
+	if err := entries.Put(k, value); err != nil {
+		return err
+	}
+	if err := age.Put(append(value[:8:8], k...), nil); err != nil {
+		return err
+	}
+
+	if err := setEntryCount(tx, count); err != nil {
+		return err
+	}
+
+	if c.opts.MaxEntries <= 0 || count <= c.opts.MaxEntries {
+		return nil
+	}
+	excess := count - c.opts.MaxEntries
+	return c.evict(tx, func(_ time.Time, evicted int) bool { return evicted < excess })
+}
+
+// evict deletes entries from oldest to newest while more reports true
+func (c *Cache) evict(tx *bolt.Tx, more func(stored time.Time, evicted int) bool) error {
+	entries, age := tx.Bucket(entriesBucket), tx.Bucket(ageBucket)
+
+	// Collect first: deleting while iterating a bbolt cursor can skip keys.
+	var expired [][]byte
+	cursor := age.Cursor()
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		stored := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+		if !more(stored, len(expired)) {
+			break
+		}
+		expired = append(expired, append([]byte(nil), k...))
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	for _, k := range expired {
+		if err := entries.Delete(k[8:]); err != nil {
+			return err
+		}
+		if err := age.Delete(k); err != nil {
+			return err
+		}
+	}
+	return setEntryCount(tx, entryCount(tx)-len(expired))
+}
+
+// entryCount returns the number of stored entries
+func entryCount(tx *bolt.Tx) int {
+	value := tx.Bucket(metaBucket).Get(countKey)
+	if len(value) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(value))
+}
+
+// setEntryCount records the number of stored entries
+func setEntryCount(tx *bolt.Tx, count int) error {
+	return tx.Bucket(metaBucket).Put(countKey, binary.BigEndian.AppendUint64(nil, uint64(max(count, 0))))
+}
+
+// cacheModel folds the options that change the vectors into the model name
+func cacheModel(req openai.EmbeddingRequest) string {
+	if req.Dimensions > 0 {
+		return fmt.Sprintf("%s/%d", req.Model, req.Dimensions)
+	}
+	return req.Model
+}
+
+// key hashes the model and input into a cache key
+func key(model, input string) []byte {
+	sum := sha256.Sum256([]byte(model + "\x00" + input))
+	return sum[:]
+}
+
+// appendVector encodes vec as little-endian float32 values
+func appendVector(dst []byte, vec []float32) []byte {
+	for _, v := range vec {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
+
+// decodeVector decodes little-endian float32 values
+func decodeVector(data []byte) []float32 {
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec
+}
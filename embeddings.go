@@ -0,0 +1,159 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// EmbeddingRequest represents an embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+	// Dimensions shortens the embeddings on models that support it
+	Dimensions int    `json:"dimensions,omitempty"`
+	User       string `json:"user,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r EmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type alias EmbeddingRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// Embedding is the vector for one input
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// EmbeddingResponse represents an embeddings response
+type EmbeddingResponse struct {
+	Object string         `json:"object"`
+	Data   []Embedding    `json:"data"`
+	Model  string         `json:"model"`
+	Usage  EmbeddingUsage `json:"usage"`
+}
+
+// EmbeddingUsage reports the tokens consumed by an embeddings request
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// CreateEmbeddings returns an embedding for every input, in input order
+func (c *Client) CreateEmbeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	var resp EmbeddingResponse
+	if err := c.Do(ctx, http.MethodPost, "/embeddings", req, &resp); err != nil {
+		return nil, err
+	}
+
+	c.recordUsage(req.Model, resp.Usage.PromptTokens, 0)
+
+	return &resp, nil
+}
+
+// embeddingScratch holds the buffers of one CreateEmbeddingsFunc call
+type embeddingScratch struct {
+	request bytes.Buffer
+	vector  []float32
+}
+
+// base64Embedding is an embedding with a base64 vector, which encoding/json
+// decodes into the byte slice
+type base64Embedding struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding []byte `json:"embedding"`
+}
+
+// embeddingListMeta holds the fields of an embeddings response besides data
+type embeddingListMeta struct {
+	Object string         `json:"object"`
+	Model  string         `json:"model"`
+	Usage  EmbeddingUsage `json:"usage"`
+}
+
+var embeddingScratchPool = sync.Pool{New: func() any { return new(embeddingScratch) }}
+
+// CreateEmbeddingsFunc is a low-allocation variant of CreateEmbeddings for
+// bulk indexing. Vectors are requested in base64, the request buffer is
+// pooled, and the response is decoded one embedding at a time. fn receives
+// every embedding with its input index in one reused []float32, which is only
+// valid until fn returns; copy it to keep it. An error from fn stops the
+// iteration and is returned. Usage is recorded only for fully read responses.
+func (c *Client) CreateEmbeddingsFunc(
+	ctx context.Context,
+	req EmbeddingRequest,
+	fn func(index int, embedding []float32) error,
+) (EmbeddingUsage, error) {
+	scratch := embeddingScratchPool.Get().(*embeddingScratch)
+	defer embeddingScratchPool.Put(scratch)
+
+	scratch.request.Reset()
+	extra := maps.Clone(req.ExtraFields)
+	if extra == nil {
+		extra = make(map[string]any, 1)
+	}
+	extra["encoding_format"] = "base64"
+	req.ExtraFields = extra
+	if err := json.NewEncoder(&scratch.request).Encode(req); err != nil {
+		return EmbeddingUsage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, "/embeddings", bytes.NewReader(scratch.request.Bytes()))
+	if err != nil {
+		return EmbeddingUsage{}, err
+	}
+	defer drainAndClose(resp.Body)
+
+	var (
+		meta  embeddingListMeta
+		fnErr error
+	)
+	err = decodeList(resp.Body, c.strict, &meta, func(data base64Embedding) error {
+		if len(data.Embedding)%4 != 0 {
+			return fmt.Errorf("embedding %d has %d bytes, not a multiple of 4", data.Index, len(data.Embedding))
+		}
+		scratch.vector = decodeFloat32s(scratch.vector, data.Embedding)
+		if fnErr = fn(data.Index, scratch.vector); fnErr != nil {
+			return errStopList
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return EmbeddingUsage{}, fnErr
+	}
+	if err != nil {
+		return EmbeddingUsage{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.recordUsage(req.Model, meta.Usage.PromptTokens, 0)
+	return meta.Usage, nil
+}
+
+// decodeFloat32s decodes little-endian float32 values from raw into dst,
+// reusing its capacity
+func decodeFloat32s(dst []float32, raw []byte) []float32 {
+	n := len(raw) / 4
+	dst = slices.Grow(dst[:0], n)[:n]
+	for i := range dst {
+		dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return dst
+}
@@ -0,0 +1,238 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies an error for presentation to end users
+type ErrorKind string
+
+// Kinds reported by ClassifyError
+const (
+	ErrorKindInvalidAPIKey     ErrorKind = "invalid_api_key"
+	ErrorKindPermissionDenied  ErrorKind = "permission_denied"
+	ErrorKindInsufficientQuota ErrorKind = "insufficient_quota"
+	ErrorKindBillingLimit      ErrorKind = "billing_limit"
+	ErrorKindRateLimited       ErrorKind = "rate_limited"
+	ErrorKindContextLength     ErrorKind = "context_length"
+	ErrorKindModelNotFound     ErrorKind = "model_not_found"
+	ErrorKindNotFound          ErrorKind = "not_found"
+	ErrorKindInvalidRequest    ErrorKind = "invalid_request"
+	ErrorKindServer            ErrorKind = "server_error"
+	ErrorKindTimeout           ErrorKind = "timeout"
+	ErrorKindNetwork           ErrorKind = "network"
+	ErrorKindCanceled          ErrorKind = "canceled"
+	ErrorKindBudgetExceeded    ErrorKind = "budget_exceeded"
+	ErrorKindClientClosed      ErrorKind = "client_closed"
+	ErrorKindRefused           ErrorKind = "refused"
+	ErrorKindUnknown           ErrorKind = "unknown"
+)
+
+// ErrorMessage is a human-friendly description of an error with a hint on how
+// to resolve it, for CLIs and TUIs
+type ErrorMessage struct {
+	Kind ErrorKind
+	// Summary says what went wrong in one sentence
+	Summary string
+	// Hint suggests what to do about it; it may be empty
+	Hint string
+	// Detail is the message from the API, when there is one
+	Detail string
+	// RetryAfter is how long to wait before retrying a rate-limited request,
+	// when the server said
+	RetryAfter time.Duration
+}
+
+// String formats the message as the summary followed by the hint
+func (m ErrorMessage) String() string {
+	if m.Hint == "" {
+		return m.Summary
+	}
+	return m.Summary + " " + m.Hint
+}
+
+// MessageCatalog maps error kinds to the Summary and Hint shown for them.
+// The placeholder {status} is replaced with the HTTP status of API errors.
+type MessageCatalog map[ErrorKind]ErrorMessage
+
+// DefaultMessageCatalog holds the English messages DescribeError falls back to
+var DefaultMessageCatalog = MessageCatalog{
+	ErrorKindInvalidAPIKey: {
+		Summary: "The API key was rejected.",
+		Hint:    "Check that OPENAI_API_KEY is set to a valid key from https://platform.openai.com/api-keys.",
+	},
+	ErrorKindPermissionDenied: {
+		Summary: "This API key is not allowed to perform the request.",
+		Hint:    "Check the key's permissions and the organization or project it belongs to.",
+	},
+	ErrorKindInsufficientQuota: {
+		Summary: "Your OpenAI account is out of credits.",
+		Hint:    "Add credits at https://platform.openai.com/settings/organization/billing.",
+	},
+	ErrorKindBillingLimit: {
+		Summary: "Your organization reached its billing limit.",
+		Hint:    "Raise the limit at https://platform.openai.com/settings/organization/limits.",
+	},
+	ErrorKindRateLimited: {
+		Summary: "Too many requests were sent in a short time.",
+		Hint:    "Wait a moment and try again.",
+	},
+	ErrorKindContextLength: {
+		Summary: "The conversation is too long for this model.",
+		Hint:    "Remove earlier messages, or switch to a model with a larger context window.",
+	},
+	ErrorKindModelNotFound: {
+		Summary: "The requested model does not exist or is not available to you.",
+		Hint:    "Check the model name, or list the models available to your key.",
+	},
+	ErrorKindNotFound: {
+		Summary: "The requested resource was not found.",
+	},
+	ErrorKindInvalidRequest: {
+		Summary: "The request was rejected as invalid.",
+		Hint:    "See the details below and adjust the request.",
+	},
+	ErrorKindServer: {
+		Summary: "OpenAI had a problem processing the request (status {status}).",
+		Hint:    "Try again shortly; see https://status.openai.com for incidents.",
+	},
+	ErrorKindTimeout: {
+		Summary: "The request timed out.",
+		Hint:    "Try again, or increase the timeout for long answers.",
+	},
+	ErrorKindNetwork: {
+		Summary: "Could not reach the OpenAI API.",
+		Hint:    "Check your network connection, proxy, and firewall settings.",
+	},
+	ErrorKindCanceled: {
+		Summary: "The request was canceled.",
+	},
+	ErrorKindBudgetExceeded: {
+		Summary: "The configured spending budget is used up.",
+		Hint:    "Wait for the budget window to roll over or raise the limit.",
+	},
+	ErrorKindClientClosed: {
+		Summary: "The client was shut down.",
+	},
+	ErrorKindRefused: {
+		Summary: "The model declined to answer.",
+		Hint:    "See the details below, and rephrase the request if appropriate.",
+	},
+	ErrorKindUnknown: {
+		Summary: "Something went wrong.",
+	},
+}
+
+// ClassifyError returns the kind of err for choosing a message
+func ClassifyError(err error) ErrorKind {
+	var (
+		apiErr *APIError
+		ctxErr *ContextLengthError
+		rlErr  *RateLimitError
+		refErr *RefusalError
+		netErr net.Error
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInsufficientQuota):
+		return ErrorKindInsufficientQuota
+	case errors.Is(err, ErrBillingHardLimit):
+		return ErrorKindBillingLimit
+	case errors.As(err, &ctxErr):
+		return ErrorKindContextLength
+	case errors.As(err, &rlErr):
+		return ErrorKindRateLimited
+	case errors.As(err, &apiErr):
+		return classifyAPIError(apiErr)
+	case errors.Is(err, ErrBudgetExceeded):
+		return ErrorKindBudgetExceeded
+	case errors.Is(err, ErrClientClosed):
+		return ErrorKindClientClosed
+	case errors.As(err, &refErr):
+		return ErrorKindRefused
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrConnectTimeout),
+		errors.Is(err, ErrResponseHeaderTimeout),
+		errors.Is(err, ErrStreamReadTimeout),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorKindTimeout
+	case errors.Is(err, ErrTLSHandshake), errors.As(err, &netErr):
+		return ErrorKindNetwork
+	}
+	return ErrorKindUnknown
+}
+
+// classifyAPIError maps an API error by code and status
+func classifyAPIError(e *APIError) ErrorKind {
+	switch e.Code {
+	case "invalid_api_key":
+		return ErrorKindInvalidAPIKey
+	case "model_not_found":
+		return ErrorKindModelNotFound
+	}
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrorKindInvalidAPIKey
+	case e.StatusCode == http.StatusForbidden:
+		return ErrorKindPermissionDenied
+	case e.StatusCode == http.StatusNotFound && e.Param == "model":
+		return ErrorKindModelNotFound
+	case e.StatusCode == http.StatusNotFound:
+		return ErrorKindNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case e.StatusCode >= 500:
+		return ErrorKindServer
+	case e.StatusCode >= 400:
+		return ErrorKindInvalidRequest
+	}
+	return ErrorKindUnknown
+}
+
+// DescribeError returns a human-friendly message for err from catalog, which
+// may hold translations. Kinds missing from catalog, or a nil catalog, use
+// DefaultMessageCatalog. It returns the zero message for a nil error.
+func DescribeError(err error, catalog MessageCatalog) ErrorMessage {
+	kind := ClassifyError(err)
+	if kind == "" {
+		return ErrorMessage{}
+	}
+	msg, ok := catalog[kind]
+	if !ok {
+		msg = DefaultMessageCatalog[kind]
+	}
+	msg.Kind = kind
+
+	var (
+		apiErr *APIError
+		rlErr  *RateLimitError
+		refErr *RefusalError
+	)
+	status := ""
+	if errors.As(err, &apiErr) {
+		msg.Detail = apiErr.Message
+		status = strconv.Itoa(apiErr.StatusCode)
+	}
+	if errors.As(err, &rlErr) {
+		msg.RetryAfter = rlErr.Wait()
+	}
+	if errors.As(err, &refErr) {
+		msg.Detail = refErr.Refusal
+	}
+	if kind == ErrorKindUnknown {
+		msg.Detail = err.Error()
+	}
+
+	msg.Summary = strings.ReplaceAll(msg.Summary, "{status}", status)
+	msg.Hint = strings.ReplaceAll(msg.Hint, "{status}", status)
+	return msg
+}
@@ -0,0 +1,330 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiErrorBody mirrors the error envelope returned by the API. The code is
+// usually a string but some gateways send numbers.
+type apiErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Param   string `json:"param"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// APIError is an unsuccessful API response. The more specific errors of this
+// package, such as *RateLimitError and *ContextLengthError, wrap it, so
+// errors.As(err, &apiErr) works for every failed response.
+type APIError struct {
+	StatusCode int
+	// Message, Type, Param, and Code come from the error envelope. Message
+	// holds the raw body when the response was not an error envelope.
+	Message string
+	Type    string
+	Param   string
+	Code    string
+	// RequestID is the server's x-request-id, for support requests
+	RequestID string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d %s): %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	var details []string
+	if e.Type != "" {
+		details = append(details, "type="+e.Type)
+	}
+	if e.Code != "" {
+		details = append(details, "code="+e.Code)
+	}
+	if e.Param != "" {
+		details = append(details, "param="+e.Param)
+	}
+	if e.RequestID != "" {
+		details = append(details, "request_id="+e.RequestID)
+	}
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg
+}
+
+// Is matches the sentinel errors identified by the error code, such as
+// ErrInsufficientQuota
+func (e *APIError) Is(target error) bool {
+	switch e.Code {
+	case "insufficient_quota":
+		return target == ErrInsufficientQuota
+	case "billing_hard_limit_reached":
+		return target == ErrBillingHardLimit
+	}
+	return false
+}
+
+// newAPIError parses the error envelope of an unsuccessful response
+func newAPIError(resp *http.Response, data []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-request-id"),
+	}
+	if !apiErr.decodeEnvelope(data) {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+// decodeEnvelope fills e from the error envelope in data, reporting whether
+// data held one
+func (e *APIError) decodeEnvelope(data []byte) bool {
+	var body apiErrorBody
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		return false
+	}
+	e.Message = body.Error.Message
+	e.Type = body.Error.Type
+	e.Param = body.Error.Param
+	switch code := body.Error.Code.(type) {
+	case string:
+		e.Code = code
+	case float64:
+		e.Code = strconv.FormatFloat(code, 'f', -1, 64)
+	}
+	return true
+}
+
+var (
+	// ErrNoChoices means the API returned a response without any choices
+	ErrNoChoices = errors.New("no completion choices returned")
+	// ErrEmptyContent means the returned choice carried no text content
+	ErrEmptyContent = errors.New("completion returned empty content")
+)
+
+// RefusalError reports a completion in which the model declined to answer,
+// which structured outputs requests return instead of off-schema JSON
+type RefusalError struct {
+	Refusal string
+}
+
+// Error implements the error interface
+func (e *RefusalError) Error() string {
+	return "model refused to answer: " + e.Refusal
+}
+
+var (
+	// ErrInsufficientQuota means the account has run out of credits or hit its
+	// usage quota; adding credits or raising the limit resolves it
+	ErrInsufficientQuota = errors.New("insufficient quota")
+	// ErrBillingHardLimit means the organization reached its billing hard limit
+	ErrBillingHardLimit = errors.New("billing hard limit reached")
+)
+
+// ContextLengthError reports a request whose prompt and requested output do
+// not fit in the model's context window. Callers can use the token counts to
+// truncate or summarize the conversation and retry.
+type ContextLengthError struct {
+	StatusCode int
+	Message    string
+	// MaxTokens is the model's context window, when the API reported it
+	MaxTokens int
+	// RequestedTokens is the size of the rejected request, when reported
+	RequestedTokens int
+	// API is the underlying API error
+	API *APIError
+}
+
+// Error implements the error interface
+func (e *ContextLengthError) Error() string {
+	return fmt.Sprintf("context length exceeded (status %d): %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the underlying API error
+func (e *ContextLengthError) Unwrap() error {
+	if e.API == nil {
+		return nil
+	}
+	return e.API
+}
+
+// Excess returns how many tokens must be removed for the request to fit, or
+// zero when the counts are unknown
+func (e *ContextLengthError) Excess() int {
+	if e.MaxTokens == 0 || e.RequestedTokens <= e.MaxTokens {
+		return 0
+	}
+	return e.RequestedTokens - e.MaxTokens
+}
+
+// RateLimitError reports a 429 response caused by request or token rate
+// limits. The delays come from the Retry-After and x-ratelimit-reset-*
+// headers so callers managing their own retries know how long to wait.
+type RateLimitError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is the delay requested by the server, or zero when absent
+	RetryAfter time.Duration
+	// ResetRequests and ResetTokens report when the request and token limits
+	// replenish, or zero when absent
+	ResetRequests time.Duration
+	ResetTokens   time.Duration
+	// RateLimit holds all x-ratelimit-* headers of the response
+	RateLimit RateLimitInfo
+	// API is the underlying API error
+	API *APIError
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("rate limited (status %d): %s", e.StatusCode, e.Message)
+	if wait := e.Wait(); wait > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", wait)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying API error
+func (e *RateLimitError) Unwrap() error {
+	if e.API == nil {
+		return nil
+	}
+	return e.API
+}
+
+// Wait returns how long to wait before retrying: RetryAfter when the server
+// sent one, otherwise the longest reported reset duration
+func (e *RateLimitError) Wait() time.Duration {
+	if e.RetryAfter > 0 {
+		return e.RetryAfter
+	}
+	return max(e.ResetRequests, e.ResetTokens)
+}
+
+// parseRetryAfter reads retry-after-ms or Retry-After, which may hold either a
+// number of seconds or an HTTP date
+func parseRetryAfter(h http.Header) time.Duration {
+	if ms := h.Get("retry-after-ms"); ms != "" {
+		if v, err := strconv.ParseFloat(ms, 64); err == nil && v > 0 {
+			return time.Duration(v * float64(time.Millisecond))
+		}
+	}
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// parseResetDuration reads an x-ratelimit-reset-* header such as "6m0s"
+func parseResetDuration(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+var (
+	maxContextRe       = regexp.MustCompile(`(?:maximum context length is|limit of) (\d+) tokens`)
+	requestedContextRe = regexp.MustCompile(`(?:you requested|resulted in) (\d+) tokens`)
+)
+
+// newResponseError converts an unsuccessful response into an *APIError,
+// wrapped in a dedicated type when the error is recognized
+func newResponseError(resp *http.Response, data []byte) error {
+	apiErr := newAPIError(resp, data)
+
+	if apiErr.Code == "context_length_exceeded" {
+		return &ContextLengthError{
+			StatusCode:      resp.StatusCode,
+			Message:         apiErr.Message,
+			MaxTokens:       matchInt(maxContextRe, apiErr.Message),
+			RequestedTokens: matchInt(requestedContextRe, apiErr.Message),
+			API:             apiErr,
+		}
+	}
+
+	// Quota errors are also sent as 429 but waiting does not resolve them.
+	if resp.StatusCode == http.StatusTooManyRequests &&
+		!errors.Is(apiErr, ErrInsufficientQuota) && !errors.Is(apiErr, ErrBillingHardLimit) {
+		info := ParseRateLimitInfo(resp.Header)
+		return &RateLimitError{
+			StatusCode:    resp.StatusCode,
+			Message:       apiErr.Message,
+			RetryAfter:    parseRetryAfter(resp.Header),
+			ResetRequests: info.ResetRequests,
+			ResetTokens:   info.ResetTokens,
+			RateLimit:     info,
+			API:           apiErr,
+		}
+	}
+
+	return apiErr
+}
+
+// matchInt returns the first captured integer of re in s, or zero
+func matchInt(re *regexp.Regexp, s string) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// maxParseErrorBytes bounds how much of an offending frame a StreamParseError
+// keeps
+const maxParseErrorBytes = 512
+
+// StreamParseError reports a stream frame that could not be parsed
+type StreamParseError struct {
+	// Offset is the byte offset of the frame's line in the stream
+	Offset int64
+	// Data holds the offending payload, cut to 512 bytes
+	Data []byte
+	// Truncated reports whether Data was cut
+	Truncated bool
+	Err       error
+}
+
+// newStreamParseError copies the offending payload so it outlives the reader's
+// buffer
+func newStreamParseError(offset int64, data []byte, err error) *StreamParseError {
+	e := &StreamParseError{Offset: offset, Err: err}
+	if len(data) > maxParseErrorBytes {
+		data, e.Truncated = data[:maxParseErrorBytes], true
+	}
+	e.Data = append([]byte(nil), data...)
+	return e
+}
+
+// Error implements the error interface
+func (e *StreamParseError) Error() string {
+	suffix := ""
+	if e.Truncated {
+		suffix = "..."
+	}
+	return fmt.Sprintf("malformed stream frame at byte %d: %v: %q%s", e.Offset, e.Err, e.Data, suffix)
+}
+
+// Unwrap returns the underlying decoding error
+func (e *StreamParseError) Unwrap() error {
+	return e.Err
+}
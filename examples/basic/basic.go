@@ -25,7 +25,7 @@ func main() {
 			{Role: "system", Content: "You are a helpful assistant that provides concise answers."},
 			{Role: "user", Content: "What is the capital of France?"},
 		},
-		Temperature:     1,
+		Temperature:     openai.Ptr[float32](1),
 		ReasoningEffort: "minimal",
 	}
 
@@ -24,7 +24,7 @@ func main() {
 			{Role: "system", Content: "You are a helpful assistant."},
 			{Role: "user", Content: "Tell me a long joke."},
 		},
-		Temperature:     1,
+		Temperature:     openai.Ptr[float32](1),
 		ReasoningEffort: "minimal",
 	}
 
@@ -30,7 +30,7 @@ func main() {
 				Content: "Explain the goroutines in Go programming language with examples in markdown format.",
 			},
 		},
-		Temperature:     1,
+		Temperature:     openai.Ptr[float32](1),
 		ReasoningEffort: "low",
 	}
 
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// AnnotatedMessage is a conversation message with its estimated token count
+// and the context used by it and every message before it
+type AnnotatedMessage struct {
+	Message Message `json:"message"`
+	// Tokens is the MessageTokens estimate of the message
+	Tokens int `json:"tokens"`
+	// CumulativeTokens is the prompt size up to and including the message
+	CumulativeTokens int `json:"cumulative_tokens"`
+	// ContextUsage is CumulativeTokens as a fraction of the model's context
+	// window; values above 1 no longer fit
+	ContextUsage float64 `json:"context_usage"`
+}
+
+// AnnotateMessages estimates the token count and cumulative context usage of
+// every message for model, to show how close a conversation is to the context
+// window
+func AnnotateMessages(messages []Message, model string) []AnnotatedMessage {
+	window := token.ContextWindow(model)
+	annotated := make([]AnnotatedMessage, len(messages))
+	total := replyPrimingTokens
+	for i, m := range messages {
+		tokens := MessageTokens(m)
+		total += tokens
+		annotated[i] = AnnotatedMessage{
+			Message:          m,
+			Tokens:           tokens,
+			CumulativeTokens: total,
+			ContextUsage:     float64(total) / float64(window),
+		}
+	}
+	return annotated
+}
+
+// ConversationExport is the document written by ExportConversation
+type ConversationExport struct {
+	Model         string             `json:"model"`
+	ContextWindow int                `json:"context_window"`
+	TotalTokens   int                `json:"total_tokens"`
+	Messages      []AnnotatedMessage `json:"messages"`
+}
+
+// ExportConversation writes messages to w as an indented JSON
+// ConversationExport, each message annotated with its token count and
+// cumulative context usage for model
+func ExportConversation(w io.Writer, messages []Message, model string) error {
+	export := ConversationExport{
+		Model:         model,
+		ContextWindow: token.ContextWindow(model),
+		TotalTokens:   replyPrimingTokens,
+		Messages:      AnnotateMessages(messages, model),
+	}
+	if n := len(export.Messages); n > 0 {
+		export.TotalTokens = export.Messages[n-1].CumulativeTokens
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to export conversation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// withExtraFields merges extra into the JSON object data. Extra fields take
+// precedence over typed fields with the same name.
+func withExtraFields(data []byte, extra map[string]any) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal extra field %q: %w", key, err)
+		}
+		fields[key] = encoded
+	}
+	return json.Marshal(fields)
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	type alias ChatCompletionRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// UnmarshalJSON decodes the response and keeps the undecoded body in Raw
+func (r *ChatCompletionResponse) UnmarshalJSON(data []byte) error {
+	type alias ChatCompletionResponse
+	if err := json.Unmarshal(data, (*alias)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// UnmarshalJSON decodes the chunk and keeps the undecoded payload in Raw
+func (r *ChatCompletionStreamResponse) UnmarshalJSON(data []byte) error {
+	type alias ChatCompletionStreamResponse
+	if err := json.Unmarshal(data, (*alias)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
@@ -0,0 +1,90 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// File purposes accepted by UploadFile
+const (
+	FilePurposeBatch      = "batch"
+	FilePurposeAssistants = "assistants"
+	FilePurposeFineTune   = "fine-tune"
+	FilePurposeVision     = "vision"
+	FilePurposeUserData   = "user_data"
+)
+
+// File describes an uploaded file
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status,omitempty"`
+}
+
+// UploadFile uploads the contents of r as filename for the given purpose
+func (c *Client) UploadFile(ctx context.Context, filename, purpose string, r io.Reader) (*File, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("purpose", purpose); err != nil {
+		return nil, fmt.Errorf("failed to build upload: %w", err)
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build upload: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build upload: %w", err)
+	}
+
+	resp, err := c.doRequestWithType(ctx, http.MethodPost, "/files", form.FormDataContentType(), &body)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	var file File
+	if err := c.decodeResponse(resp.Body, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &file, nil
+}
+
+// GetFile retrieves a file's metadata
+func (c *Client) GetFile(ctx context.Context, id string) (*File, error) {
+	var file File
+	if err := c.Do(ctx, http.MethodGet, "/files/"+url.PathEscape(id), nil, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetFileContent returns the contents of a file. The caller must close it.
+func (c *Client) GetFileContent(ctx context.Context, id string) (io.ReadCloser, error) {
+	resp, err := c.doJSON(ctx, http.MethodGet, "/files/"+url.PathEscape(id)+"/content", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteFile deletes a file
+func (c *Client) DeleteFile(ctx context.Context, id string) error {
+	return c.Do(ctx, http.MethodDelete, "/files/"+url.PathEscape(id), nil, nil)
+}
+
+// ListFiles lists uploaded files
+func (c *Client) ListFiles(opts ListOptions) *Pager[File] {
+	return NewPager(c, "/files", opts, func(f File) string { return f.ID })
+}
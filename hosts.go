@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type baseURLKey struct{}
+
+// requestBaseURL is a validated base URL carried by a context
+type requestBaseURL struct {
+	url string
+	err error
+}
+
+// WithRequestBaseURL returns a context whose calls are sent to rawURL instead
+// of the client's base URL, so one client can serve both OpenAI and a mirror
+// or gateway. rawURL follows the rules of WithBaseURL; an invalid one fails
+// the calls with ErrInvalidBaseURL.
+func WithRequestBaseURL(ctx context.Context, rawURL string) context.Context {
+	url, err := parseBaseURL(rawURL)
+	return context.WithValue(ctx, baseURLKey{}, requestBaseURL{url: url, err: err})
+}
+
+// baseURLFor returns the base URL of a call made with ctx
+func (c *Client) baseURLFor(ctx context.Context) (string, error) {
+	if base, ok := ctx.Value(baseURLKey{}).(requestBaseURL); ok {
+		return base.url, base.err
+	}
+	return c.baseURL, c.baseURLErr
+}
+
+// hostTransports gives every host its own clone of a base transport, created
+// on first use, so the connection pools and limits of the API and a mirror do
+// not compete
+type hostTransports struct {
+	base *http.Transport
+
+	mu     sync.Mutex
+	byHost map[string]*http.Transport
+}
+
+// newHostTransports wraps base, or http.DefaultTransport when base is nil.
+// It returns nil for custom round trippers, which are used as they are.
+func newHostTransports(base http.RoundTripper) *hostTransports {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	return &hostTransports{base: transport, byHost: make(map[string]*http.Transport)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *hostTransports) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.forHost(req.URL.Host).RoundTrip(req)
+}
+
+// forHost returns the transport of host, creating it if needed
+func (t *hostTransports) forHost(host string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	transport, ok := t.byHost[host]
+	if !ok {
+		transport = t.base.Clone()
+		t.byHost[host] = transport
+	}
+	return transport
+}
+
+// CloseIdleConnections closes the idle connections of every host
+func (t *hostTransports) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, transport := range t.byHost {
+		transport.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,89 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// IdempotencyHeader carries the idempotency key of a POST request. Retries of
+// a call reuse its key, so the server can recognize them and not run the
+// request twice.
+const IdempotencyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// idempotencyKeys hands out the keys of the POST requests made with a context
+// of WithIdempotencyKey: the first gets key itself, the nth after it key-n
+type idempotencyKeys struct {
+	key  string
+	sent atomic.Int64
+}
+
+// next returns the key of the next POST request
+func (k *idempotencyKeys) next() string {
+	n := k.sent.Add(1) - 1
+	if n == 0 {
+		return k.key
+	}
+	return fmt.Sprintf("%s-%d", k.key, n)
+}
+
+// WithIdempotencyKeys attaches a random UUID Idempotency-Key to every POST
+// request, kept across the automatic retries of WithMaxRetries, so a retry
+// after a lost response does not bill twice or submit a batch or fine-tuning
+// job again
+func WithIdempotencyKeys() ClientOption {
+	return func(c *Client) {
+		c.idempotency = true
+	}
+}
+
+// WithIdempotencyKey returns a context whose POST requests derive their
+// Idempotency-Key from key, with or without WithIdempotencyKeys, so a job can
+// be resubmitted safely after the process restarts. The first POST request
+// made with the context sends key and the nth after it key-n, so helpers
+// making several requests, such as an upload followed by a batch or the turns
+// of a ToolRunner, do not have them collapsed into one by the server. The keys
+// match across restarts as long as the requests are made in the same order;
+// helpers sending requests concurrently derive theirs from what they send,
+// such as key-part-3 for the fourth part of UploadLargeFile.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, &idempotencyKeys{key: key})
+}
+
+// withIdempotencyScope returns a context whose POST requests derive their keys
+// from the key of ctx suffixed with scope, or ctx when it has no key, for
+// requests whose order is not fixed
+func withIdempotencyScope(ctx context.Context, scope string) context.Context {
+	keys, ok := ctx.Value(idempotencyKey{}).(*idempotencyKeys)
+	if !ok || keys.key == "" {
+		return ctx
+	}
+	return WithIdempotencyKey(ctx, keys.key+"-"+scope)
+}
+
+// idempotencyKeyFor returns the key of a call, or "" when it should not send one
+func (c *Client) idempotencyKeyFor(ctx context.Context, method string) string {
+	if method != http.MethodPost {
+		return ""
+	}
+	if keys, ok := ctx.Value(idempotencyKey{}).(*idempotencyKeys); ok && keys.key != "" {
+		return keys.next()
+	}
+	if !c.idempotency {
+		return ""
+	}
+	return newUUID()
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
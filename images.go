@@ -0,0 +1,229 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Image stream event types
+const (
+	ImageEditPartialImage = "image_edit.partial_image"
+	ImageEditCompleted    = "image_edit.completed"
+)
+
+// ImageFile is an image uploaded with a request. Name is the file name, whose
+// extension, such as ".png", sets the content type the API checks.
+type ImageFile struct {
+	Name   string
+	Reader io.Reader
+}
+
+// ImageEditRequest edits or extends images according to a prompt
+type ImageEditRequest struct {
+	// Image is the source image; gpt-image-1 accepts up to 16 images, used as
+	// references for the result
+	Image []ImageFile
+	// Mask, when set, is a PNG whose fully transparent areas mark where the
+	// first image is edited
+	Mask   *ImageFile
+	Prompt string
+	// Model is "dall-e-2" or "gpt-image-1"
+	Model string
+	N     int
+	// Size is such as "1024x1024" or "auto"
+	Size string
+	// Quality is "low", "medium", "high", or "auto" for gpt-image-1 and
+	// "standard" for dall-e-2
+	Quality string
+	// ResponseFormat is "url" or "b64_json" for dall-e-2; gpt-image-1
+	// always returns base64
+	ResponseFormat string
+	// Background, OutputFormat, and InputFidelity apply to gpt-image-1 only
+	Background    string
+	OutputFormat  string
+	InputFidelity string
+	// PartialImages is the number of partial images, up to 3, sent by
+	// CreateImageEditStream before the final one
+	PartialImages int
+	User          string
+}
+
+// ImageVariationRequest creates variations of an image, with dall-e-2
+type ImageVariationRequest struct {
+	// Image is a square PNG under 4 MB
+	Image          ImageFile
+	Model          string
+	N              int
+	Size           string
+	ResponseFormat string
+	User           string
+}
+
+// ImageData is one generated image, given by URL or as base64 depending on
+// the model and response format
+type ImageData struct {
+	URL     string `json:"url,omitempty"`
+	B64JSON string `json:"b64_json,omitempty"`
+	// RevisedPrompt is the prompt the model actually used, when it rewrote it
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Bytes decodes B64JSON
+func (d ImageData) Bytes() ([]byte, error) {
+	return decodeImage(d.B64JSON)
+}
+
+// ImageUsage reports the tokens consumed by a gpt-image-1 request
+type ImageUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ImageResponse represents an image edit or variation response
+type ImageResponse struct {
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+	// Usage is set for gpt-image-1
+	Usage        *ImageUsage `json:"usage,omitempty"`
+	Background   string      `json:"background,omitempty"`
+	OutputFormat string      `json:"output_format,omitempty"`
+	Quality      string      `json:"quality,omitempty"`
+	Size         string      `json:"size,omitempty"`
+}
+
+// ImageStreamEvent is a partial or the final image of an image stream
+type ImageStreamEvent struct {
+	// Type is ImageEditPartialImage or ImageEditCompleted
+	Type              string `json:"type"`
+	B64JSON           string `json:"b64_json"`
+	PartialImageIndex int    `json:"partial_image_index,omitempty"`
+	CreatedAt         int64  `json:"created_at,omitempty"`
+	Size              string `json:"size,omitempty"`
+	Quality           string `json:"quality,omitempty"`
+	Background        string `json:"background,omitempty"`
+	OutputFormat      string `json:"output_format,omitempty"`
+	// Usage is set on the completed event
+	Usage *ImageUsage `json:"usage,omitempty"`
+}
+
+// Bytes decodes B64JSON
+func (e ImageStreamEvent) Bytes() ([]byte, error) {
+	return decodeImage(e.B64JSON)
+}
+
+// ImageStream reads the events of a streaming image request
+type ImageStream struct {
+	events *eventReader
+	strict bool
+}
+
+// Recv returns the next event, or io.EOF after the last one. An error event
+// is returned as an *APIError.
+func (s *ImageStream) Recv() (ImageStreamEvent, error) {
+	ev, err := s.events.next()
+	if err != nil {
+		return ImageStreamEvent{}, err
+	}
+	if ev.name == "error" {
+		return ImageStreamEvent{}, streamEventError(ev.data)
+	}
+	var event ImageStreamEvent
+	if err := json.Unmarshal(ev.data, &event); err == nil && event.Type == "error" {
+		return ImageStreamEvent{}, streamEventError(ev.data)
+	}
+	if err := decodeJSON(ev.data, &event, s.strict); err != nil {
+		return ImageStreamEvent{}, fmt.Errorf("failed to decode stream event: %w", err)
+	}
+	return event, nil
+}
+
+// Close closes the stream
+func (s *ImageStream) Close() error {
+	return s.events.close()
+}
+
+// CreateImageEdit edits the request's images according to its prompt
+func (c *Client) CreateImageEdit(ctx context.Context, req ImageEditRequest) (*ImageResponse, error) {
+	form := imageEditForm(req)
+	var resp ImageResponse
+	if err := c.postForm(ctx, "/images/edits", form, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateImageEditStream is like CreateImageEdit but streams req.PartialImages
+// partial images as they are rendered, followed by the final image. It is
+// supported by gpt-image-1. The caller must close the stream.
+func (c *Client) CreateImageEditStream(ctx context.Context, req ImageEditRequest) (*ImageStream, error) {
+	form := imageEditForm(req)
+	form.field("stream", "true")
+	contentType, body, err := form.finish()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doRequestWithType(withStreaming(ctx), http.MethodPost, "/images/edits", contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageStream{events: newEventReader(resp.Body), strict: c.strict}, nil
+}
+
+// CreateImageVariation creates variations of the request's image
+func (c *Client) CreateImageVariation(ctx context.Context, req ImageVariationRequest) (*ImageResponse, error) {
+	form := newFormBuilder()
+	form.file("image", req.Image.Name, req.Image.Reader)
+	form.field("model", req.Model)
+	form.intField("n", req.N)
+	form.field("size", req.Size)
+	form.field("response_format", req.ResponseFormat)
+	form.field("user", req.User)
+
+	var resp ImageResponse
+	if err := c.postForm(ctx, "/images/variations", form, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// imageEditForm encodes the fields of req; several images are sent as
+// image[], as the API expects
+func imageEditForm(req ImageEditRequest) *formBuilder {
+	form := newFormBuilder()
+	name := "image"
+	if len(req.Image) > 1 {
+		name = "image[]"
+	}
+	for _, image := range req.Image {
+		form.file(name, image.Name, image.Reader)
+	}
+	if req.Mask != nil {
+		form.file("mask", req.Mask.Name, req.Mask.Reader)
+	}
+	form.field("prompt", req.Prompt)
+	form.field("model", req.Model)
+	form.intField("n", req.N)
+	form.field("size", req.Size)
+	form.field("quality", req.Quality)
+	form.field("response_format", req.ResponseFormat)
+	form.field("background", req.Background)
+	form.field("output_format", req.OutputFormat)
+	form.field("input_fidelity", req.InputFidelity)
+	form.intField("partial_images", req.PartialImages)
+	form.field("user", req.User)
+	return form
+}
+
+// decodeImage decodes a base64 image
+func decodeImage(b64 string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return data, nil
+}
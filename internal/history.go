@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// Turn is a prior message of the conversation shown above the streaming
+// answer.
+type Turn struct {
+	Role    string
+	Content string
+}
+
+// roleLabel returns the heading shown above a turn.
+func roleLabel(role string) string {
+	switch role {
+	case "user":
+		return "You"
+	case "assistant":
+		return "Assistant"
+	case "":
+		return "Message"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// renderHistory renders turns as one markdown document: every turn gets a
+// bold role label, user turns are block-quoted, and system and developer
+// turns are set in italics so they recede behind the dialogue.
+func renderHistory(rend *glamour.TermRenderer, turns []Turn) (string, error) {
+	var doc strings.Builder
+	for _, turn := range turns {
+		content := strings.TrimSpace(turn.Content)
+		if content == "" {
+			continue
+		}
+		doc.WriteString("**" + roleLabel(turn.Role) + "**\n\n")
+		switch turn.Role {
+		case "user":
+			for line := range strings.SplitSeq(content, "\n") {
+				// Trailing spaces keep the user's line breaks.
+				doc.WriteString("> " + line + "  \n")
+			}
+		case "system", "developer":
+			for line := range strings.SplitSeq(content, "\n") {
+				if strings.TrimSpace(line) == "" {
+					doc.WriteString("\n")
+					continue
+				}
+				doc.WriteString("*" + strings.TrimSpace(line) + "*\n")
+			}
+		default:
+			doc.WriteString(content + "\n")
+		}
+		doc.WriteString("\n")
+	}
+	if doc.Len() == 0 {
+		return "", nil
+	}
+
+	rendered, err := rend.Render(doc.String())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRightFunc(rendered, unicode.IsSpace) + "\n", nil
+}
@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// InterruptReason tells why a stream was interrupted.
+type InterruptReason string
+
+const (
+	// InterruptUser means the user pressed Ctrl+C in the viewport.
+	InterruptUser InterruptReason = "user"
+	// InterruptContext means the caller's context was canceled or expired.
+	InterruptContext InterruptReason = "context"
+)
+
+// InterruptedError is returned when a stream ends before completion because
+// it was interrupted. It carries what was streamed so far so applications can
+// offer to resume or save the partial answer, and unwraps to
+// context.Canceled or the context's error.
+type InterruptedError struct {
+	Reason InterruptReason
+	// Content is the markdown source received before the interruption.
+	Content string
+	// BytesRendered is the size of the rendered output shown at the time.
+	BytesRendered int
+	// ScrollOffset is the first visible line of the viewport.
+	ScrollOffset int
+	Err          error
+}
+
+// Error implements the error interface.
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("stream interrupted (%s) after %d bytes: %v", e.Reason, len(e.Content), e.Err)
+}
+
+// Unwrap returns the cancellation error.
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// interruption wraps err in an InterruptedError when it stems from the user or
+// ctx ending the stream, and returns it unchanged otherwise.
+func interruption(ctx context.Context, err error, content string, rendered, offset int) error {
+	if err == nil {
+		return nil
+	}
+	var interrupted *InterruptedError
+	if errors.As(err, &interrupted) {
+		return err
+	}
+
+	reason := InterruptUser
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		reason, err = InterruptContext, ctxErr
+	} else if !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &InterruptedError{
+		Reason:        reason,
+		Content:       content,
+		BytesRendered: rendered,
+		ScrollOffset:  offset,
+		Err:           err,
+	}
+}
@@ -2,8 +2,10 @@ package markdown
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"time"
 	"unicode"
@@ -19,27 +21,128 @@ type StreamOptions struct {
 	WordWrap int
 	Cancel   func()
 	UIWriter io.Writer
+	// Transforms are applied in order to the text of every delta before it is
+	// rendered, in both raw and viewport modes.
+	Transforms []func(string) string
+	// DeltaLog, when set, receives every raw delta with a timestamp before any
+	// transform is applied, for comparing what arrived with what was displayed.
+	DeltaLog io.Writer
+	// OnComplete, when set, is called once streaming ends (successfully or
+	// not) with timing and throughput statistics.
+	OnComplete func(StreamStats)
+	// MaxOutputBytes and MaxOutputTokens end the stream once that much output
+	// has been produced, keeping what was rendered. Zero means no limit.
+	MaxOutputBytes  int
+	MaxOutputTokens int
+	// FinalWriters receive the same final output as the main writer. Terminal
+	// control sequences are only ever written to the UI writer.
+	FinalWriters []io.Writer
+	// Pipeable keeps the interactive viewport on the terminal while writing the
+	// final answer as plain markdown source when the content writer is not a
+	// terminal, so `cmd > answer.md` or `cmd | less` get clean text.
+	Pipeable bool
+	// Compat avoids cursor movement and animation for dumb terminals and CI
+	// log viewers: a static "Working..." line is printed to the UI writer and
+	// the answer is rendered once it is complete. It is enabled automatically
+	// when TERM=dumb.
+	Compat bool
+	// Color overrides color detection. The default, ColorAuto, honors NO_COLOR
+	// and CLICOLOR_FORCE before falling back to terminal detection.
+	Color ColorMode
+	// Background overrides background detection for the built-in styles.
+	Background Background
+	// StylePath or StyleJSON supply a Glamour style sheet replacing the built-in
+	// styles. It is validated before streaming starts; StyleJSON wins when both
+	// are set.
+	StylePath string
+	StyleJSON []byte
+	// ChunkTimeout, when positive, bounds the wait for each chunk. A stalled
+	// producer ends the stream with ErrChunkTimeout, shown in the viewport in
+	// place of the loader. Heartbeats reset the deadline.
+	ChunkTimeout time.Duration
+	// Coalesce buffers deltas and emits them at sentence or paragraph
+	// boundaries, for smoother raw output to TTS pipelines and log files.
+	// Whatever remains is emitted when the stream ends.
+	Coalesce CoalesceMode
+	// MaxCharsPerSecond, when positive, smooths bursty chunk arrival into a
+	// steady typing effect of at most that many characters per second, for
+	// demos and screencasts. Text arriving faster than the pace is queued.
+	MaxCharsPerSecond int
+	// History holds prior turns of the conversation, rendered above the
+	// streaming answer in the viewport and styled by role, so REPL-style tools
+	// can keep the whole conversation in view. It is not part of the final
+	// output, and raw and headless modes ignore it.
+	History []Turn
+	// Regenerate, when set, is called when the user presses Ctrl+R in the
+	// viewport to discard the answer and ask for a new one. The chunk source
+	// must then send a Chunk with Reset set before the text of the new answer.
+	// CreateChatCompletionStreamWithMarkdown always wires it to re-submit the
+	// request, at RegenerateTemperature when it is positive, and still calls a
+	// Regenerate set by the caller.
+	Regenerate            func()
+	RegenerateTemperature float32
 }
 
+// CoalesceMode selects the boundaries at which buffered deltas are emitted.
+type CoalesceMode int
+
+const (
+	// CoalesceNone emits every delta as it arrives.
+	CoalesceNone CoalesceMode = iota
+	// CoalesceSentence emits complete sentences and lines.
+	CoalesceSentence
+	// CoalesceParagraph emits complete paragraphs.
+	CoalesceParagraph
+)
+
 // Chunk represents an incremental markdown fragment emitted by the stream.
 type Chunk struct {
 	Text string
+	// Heartbeat marks a keep-alive signal from the producer. It carries no
+	// text and is counted in StreamStats instead of being rendered.
+	Heartbeat bool
+	// Reset discards the text received so far, for a producer restarting its
+	// answer after StreamOptions.Regenerate.
+	Reset bool
 }
 
+// compatWorkingLine is the static progress line shown in compatibility mode.
+const compatWorkingLine = "Working..."
+
+// ChunkSource produces the next chunk of a stream, returning io.EOF once the
+// stream is complete.
+type ChunkSource func(context.Context) (Chunk, error)
+
 // StreamMarkdown renders streaming markdown to the supplied writer. When Raw is
 // false it spins up a Bubble Tea viewport so the output remains scrollable and
 // responsive.
 func StreamMarkdown(
 	ctx context.Context,
-	next func(context.Context) (Chunk, error),
+	next ChunkSource,
 	w io.Writer,
 	opts StreamOptions,
 ) error {
 	chunkCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if opts.OnComplete != nil {
+		recorder := newStatsRecorder()
+		next = recorder.wrap(next)
+		defer func() { opts.OnComplete(recorder.finish()) }()
+	}
+
+	next = buildPipeline(next, opts)
+
+	uiWriter := resolveUIWriter(w, opts)
+	plainFinal := opts.Pipeable && !isTerminal(w)
+	if len(opts.FinalWriters) > 0 {
+		w = io.MultiWriter(append([]io.Writer{w}, opts.FinalWriters...)...)
+	}
+
 	if opts.Raw {
-		return streamRaw(chunkCtx, next, w)
+		var content strings.Builder
+		err := streamRaw(chunkCtx, next, io.MultiWriter(w, &content))
+		return interruption(ctx, err, content.String(), content.Len(), 0)
 	}
 
 	rend, err := newTermRenderer(opts)
@@ -47,11 +150,26 @@ func StreamMarkdown(
 		return err
 	}
 
-	return streamWithViewport(ctx, chunkCtx, next, w, rend, cancel, opts.Cancel, opts)
+	if opts.Compat || os.Getenv("TERM") == "dumb" {
+		if uiWriter != nil {
+			_, _ = fmt.Fprintln(uiWriter, compatWorkingLine)
+		}
+		uiWriter = nil
+	}
+
+	if uiWriter == nil {
+		if plainFinal {
+			return streamRaw(chunkCtx, next, w)
+		}
+		return streamHeadless(chunkCtx, next, w, rend)
+	}
+
+	opts.UIWriter = uiWriter
+	return streamWithViewport(ctx, chunkCtx, next, w, rend, plainFinal, cancel, opts.Cancel, opts)
 }
 
 // streamRaw simply writes chunks as they arrive without any terminal UI.
-func streamRaw(ctx context.Context, next func(context.Context) (Chunk, error), w io.Writer) error {
+func streamRaw(ctx context.Context, next ChunkSource, w io.Writer) error {
 	for {
 		chunk, err := next(ctx)
 		if err == io.EOF {
@@ -69,13 +187,39 @@ func streamRaw(ctx context.Context, next func(context.Context) (Chunk, error), w
 	}
 }
 
+// streamHeadless renders the complete markdown once the stream ends, for when
+// no terminal is available to host the interactive viewport.
+func streamHeadless(
+	ctx context.Context,
+	next ChunkSource,
+	w io.Writer,
+	rend *glamour.TermRenderer,
+) error {
+	var content strings.Builder
+	if err := streamRaw(ctx, next, &content); err != nil {
+		return interruption(ctx, err, content.String(), 0, 0)
+	}
+	if content.Len() == 0 {
+		return nil
+	}
+
+	rendered, err := rend.Render(content.String())
+	if err != nil {
+		return err
+	}
+	rendered = strings.TrimRightFunc(rendered, unicode.IsSpace) + "\n"
+	_, err = io.WriteString(w, rendered)
+	return err
+}
+
 // streamWithViewport wires the chunk source into the Bubble Tea viewport model.
 func streamWithViewport(
 	ctx context.Context,
 	chunkCtx context.Context,
-	next func(context.Context) (Chunk, error),
+	next ChunkSource,
 	w io.Writer,
 	rend *glamour.TermRenderer,
+	plainFinal bool,
 	cancel func(),
 	onInterrupt func(),
 	opts StreamOptions,
@@ -83,11 +227,14 @@ func streamWithViewport(
 	model := newMarkdownModel(rend, func() tea.Cmd {
 		return waitForChunk(chunkCtx, next)
 	}, cancel, onInterrupt)
+	history, err := renderHistory(rend, opts.History)
+	if err != nil {
+		return err
+	}
+	model.history = history
+	model.regenerate = opts.Regenerate
 
 	uiWriter := opts.UIWriter
-	if uiWriter == nil {
-		uiWriter = w
-	}
 
 	prog := tea.NewProgram(
 		model,
@@ -95,17 +242,24 @@ func streamWithViewport(
 		tea.WithOutput(uiWriter),
 	)
 
+	runErr := func(err error) error {
+		return interruption(ctx, err, model.content.String(), len(model.rendered), model.viewport.YOffset)
+	}
 	if _, err := prog.Run(); err != nil {
-		return err
+		return runErr(err)
 	}
 
 	if model.err != nil {
-		return model.err
+		return runErr(model.err)
 	}
 
 	clearViewport(uiWriter, model.lastView)
 
-	if rendered := model.rendered; rendered != "" {
+	rendered := model.rendered
+	if plainFinal {
+		rendered = model.content.String()
+	}
+	if rendered != "" {
 		if !strings.HasSuffix(rendered, "\n") {
 			rendered += "\n"
 		}
@@ -123,14 +277,19 @@ func newTermRenderer(opts StreamOptions) (*glamour.TermRenderer, error) {
 	if opts.WordWrap > 0 {
 		wrap = opts.WordWrap
 	}
+	styleOpts, err := styleOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 	return glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(wrap),
+		append(styleOpts, glamour.WithWordWrap(wrap))...,
 	)
 }
 
 type chunkMsg string
 
+type resetMsg struct{}
+
 type doneMsg struct {
 	err error
 }
@@ -144,7 +303,7 @@ type startStreamMsg struct{}
 // waitForChunk blocks until the next chunk arrives or the context is canceled.
 func waitForChunk(
 	ctx context.Context,
-	next func(context.Context) (Chunk, error),
+	next ChunkSource,
 ) tea.Cmd {
 	return func() tea.Msg {
 		chunk, err := next(ctx)
@@ -154,6 +313,9 @@ func waitForChunk(
 		if err != nil {
 			return doneMsg{err: err}
 		}
+		if chunk.Reset {
+			return resetMsg{}
+		}
 		return chunkMsg(chunk.Text)
 	}
 }
@@ -163,11 +325,13 @@ type markdownModel struct {
 	viewport     viewport.Model
 	content      strings.Builder
 	rendered     string
+	history      string
 	windowWidth  int
 	windowHeight int
 	nextChunk    func() tea.Cmd
 	cancel       func()
 	onInterrupt  func()
+	regenerate   func()
 	err          error
 	loader       *loader
 	lastView     string
@@ -212,6 +376,8 @@ func (m *markdownModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 		return m, m.next()
+	case resetMsg:
+		return m, m.reset()
 	case doneMsg:
 		if msg.err != nil {
 			m.err = msg.err
@@ -229,16 +395,27 @@ func (m *markdownModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = context.Canceled
 			return m, tea.Quit
 		}
+		if msg.Type == tea.KeyCtrlR && m.regenerate != nil {
+			m.regenerate()
+			return m, nil
+		}
 	case tea.WindowSizeMsg:
 		m.windowWidth = msg.Width
 		m.windowHeight = msg.Height
 		m.viewport.Width = msg.Width
 		m.resizeViewport()
-		m.viewport.SetContent(m.rendered)
+		m.viewport.SetContent(m.viewContent())
+		if m.content.Len() == 0 {
+			m.viewport.GotoBottom()
+		}
 		return m, nil
 	case loaderStepMsg:
 		if m.loader.active {
 			m.loader.update()
+			if !m.loader.active && m.history != "" {
+				m.resizeViewport()
+				m.viewport.GotoBottom()
+			}
 			return m, m.loaderStepCmd()
 		}
 		return m, nil
@@ -257,16 +434,33 @@ func (m *markdownModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// View renders either the loader animation or the markdown viewport.
+// View renders either the loader animation or the markdown viewport, followed
+// by the error that ended the stream, if any.
 func (m *markdownModel) View() string {
+	if m.err != nil && !errors.Is(m.err, context.Canceled) {
+		m.lastView = m.errorView()
+		return m.lastView
+	}
 	if m.loader.active {
 		m.lastView = m.loader.View()
+		if m.history != "" {
+			m.lastView = m.viewport.View() + "\n" + m.lastView
+		}
 		return m.lastView
 	}
 	m.lastView = m.viewport.View()
 	return m.lastView
 }
 
+// errorView renders whatever output arrived followed by the stream error.
+func (m *markdownModel) errorView() string {
+	view := ""
+	if m.viewContent() != "" {
+		view = m.viewport.View() + "\n"
+	}
+	return view + "Error: " + m.err.Error() + "\n"
+}
+
 // next requests the next chunk if the producer command is set.
 func (m *markdownModel) next() tea.Cmd {
 	if m.nextChunk == nil {
@@ -295,12 +489,24 @@ func (m *markdownModel) appendChunk(text string) error {
 	rendered = strings.TrimRightFunc(rendered, unicode.IsSpace) + "\n"
 	m.rendered = rendered
 	m.resizeViewport()
-	m.viewport.SetContent(rendered)
+	m.viewport.SetContent(m.viewContent())
 	m.viewport.GotoBottom()
 
 	return nil
 }
 
+// reset clears the answer for a regenerated one and brings the loader back
+// until its first chunk arrives.
+func (m *markdownModel) reset() tea.Cmd {
+	m.content.Reset()
+	m.rendered = ""
+	m.loader = newLoader()
+	m.resizeViewport()
+	m.viewport.SetContent(m.viewContent())
+	m.viewport.GotoBottom()
+	return tea.Batch(m.loaderStepCmd(), m.ellipsisTickCmd(), m.next())
+}
+
 // resizeViewport adapts the viewport height to fit either the window or the
 // current content.
 func (m *markdownModel) resizeViewport() {
@@ -309,6 +515,9 @@ func (m *markdownModel) resizeViewport() {
 	height := m.windowHeight
 	if height == 0 {
 		height = m.viewport.Height
+	} else if m.loader.active && m.history != "" {
+		// Leave room for the loader line below the history.
+		height--
 	}
 
 	if contentHeight > 0 && (height == 0 || contentHeight < height) {
@@ -322,12 +531,14 @@ func (m *markdownModel) resizeViewport() {
 	m.viewport.Height = height
 }
 
+// viewContent returns the rendered history followed by the answer so far.
+func (m *markdownModel) viewContent() string {
+	return m.history + m.rendered
+}
+
 // contentLineCount returns the number of lines currently rendered.
 func (m *markdownModel) contentLineCount() int {
-	if m.rendered == "" {
-		return 0
-	}
-	return strings.Count(m.rendered, "\n")
+	return strings.Count(m.viewContent(), "\n")
 }
 
 // loaderStepCmd schedules the next loader animation tick when active.
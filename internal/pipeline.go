@@ -0,0 +1,309 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// buildPipeline wraps the chunk producer with the per-chunk processing stages
+// configured in opts, in the order they should see the raw deltas.
+func buildPipeline(
+	next ChunkSource,
+	opts StreamOptions,
+) ChunkSource {
+	if opts.ChunkTimeout > 0 {
+		next = withChunkTimeout(next, opts.ChunkTimeout)
+	}
+	next = skipHeartbeats(next)
+	if opts.DeltaLog != nil {
+		next = withDeltaLog(next, opts.DeltaLog)
+	}
+	if len(opts.Transforms) > 0 {
+		next = withTransforms(next, opts.Transforms)
+	}
+	if opts.MaxOutputBytes > 0 || opts.MaxOutputTokens > 0 {
+		next = withOutputLimit(next, opts.MaxOutputBytes, opts.MaxOutputTokens)
+	}
+	if opts.Coalesce != CoalesceNone {
+		next = withCoalescing(next, opts.Coalesce)
+	}
+	if opts.MaxCharsPerSecond > 0 {
+		next = withPacing(next, opts.MaxCharsPerSecond)
+	}
+	return next
+}
+
+// ErrChunkTimeout is returned when the producer does not deliver a chunk
+// within StreamOptions.ChunkTimeout.
+var ErrChunkTimeout = errors.New("timed out waiting for the next chunk")
+
+// withChunkTimeout bounds every call to next by timeout. It runs ahead of
+// skipHeartbeats so keep-alive chunks reset the deadline. The producer must
+// honor context cancellation for the deadline to take effect.
+func withChunkTimeout(next ChunkSource, timeout time.Duration) ChunkSource {
+	return func(ctx context.Context) (Chunk, error) {
+		chunkCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		chunk, err := next(chunkCtx)
+		if err != nil && ctx.Err() == nil && errors.Is(chunkCtx.Err(), context.DeadlineExceeded) {
+			return Chunk{}, fmt.Errorf("%w after %s", ErrChunkTimeout, timeout)
+		}
+		return chunk, err
+	}
+}
+
+// skipHeartbeats consumes keep-alive chunks so later stages only see text.
+func skipHeartbeats(next ChunkSource) ChunkSource {
+	return func(ctx context.Context) (Chunk, error) {
+		for {
+			chunk, err := next(ctx)
+			if err != nil || !chunk.Heartbeat {
+				return chunk, err
+			}
+		}
+	}
+}
+
+// withTransforms applies every transformer, in order, to the text of each
+// chunk before it reaches the renderer.
+func withTransforms(
+	next ChunkSource,
+	transforms []func(string) string,
+) ChunkSource {
+	return func(ctx context.Context) (Chunk, error) {
+		chunk, err := next(ctx)
+		if err != nil || chunk.Reset {
+			return chunk, err
+		}
+		for _, transform := range transforms {
+			chunk.Text = transform(chunk.Text)
+		}
+		return chunk, nil
+	}
+}
+
+// withDeltaLog writes every raw delta, as it arrived and before any transform,
+// to w as a timestamped, quoted line. Write errors are ignored so a broken log
+// never interrupts the stream.
+func withDeltaLog(
+	next ChunkSource,
+	w io.Writer,
+) ChunkSource {
+	return func(ctx context.Context) (Chunk, error) {
+		chunk, err := next(ctx)
+		switch {
+		case err != nil:
+		case chunk.Reset:
+			_, _ = fmt.Fprintf(w, "%s reset\n", time.Now().Format(time.RFC3339Nano))
+		default:
+			_, _ = fmt.Fprintf(w, "%s %q\n", time.Now().Format(time.RFC3339Nano), chunk.Text)
+		}
+		return chunk, err
+	}
+}
+
+// withOutputLimit ends the stream once maxBytes bytes or maxTokens estimated
+// tokens have been produced, cutting the chunk that crosses the limit. A zero
+// limit is ignored. Returning io.EOF lets the caller stop the underlying
+// request while keeping everything produced so far.
+func withOutputLimit(next ChunkSource, maxBytes, maxTokens int) ChunkSource {
+	var (
+		produced int
+		tokens   token.Counter
+		reached  bool
+	)
+
+	fits := func(n int, c token.Counter) bool {
+		if maxBytes > 0 && n > maxBytes {
+			return false
+		}
+		if maxTokens > 0 && c.Tokens() > maxTokens {
+			return false
+		}
+		return true
+	}
+
+	return func(ctx context.Context) (Chunk, error) {
+		if reached {
+			return Chunk{}, io.EOF
+		}
+
+		chunk, err := next(ctx)
+		if err == nil && chunk.Reset {
+			produced, tokens = 0, token.Counter{}
+			return chunk, nil
+		}
+		counted := tokens
+		counted.Add(chunk.Text)
+		if err != nil || fits(produced+len(chunk.Text), counted) {
+			produced, tokens = produced+len(chunk.Text), counted
+			return chunk, err
+		}
+
+		reached = true
+		cut := 0
+		for i, r := range chunk.Text {
+			end := i + utf8.RuneLen(r)
+			tokens.Add(string(r))
+			if !fits(produced+end, tokens) {
+				break
+			}
+			cut = end
+		}
+		chunk.Text = chunk.Text[:cut]
+		if chunk.Text == "" {
+			return Chunk{}, io.EOF
+		}
+		return chunk, nil
+	}
+}
+
+// withCoalescing buffers text until it holds a complete sentence or paragraph
+// and emits everything up to the last boundary. The remainder is flushed
+// before the producer's final error, including io.EOF, is returned.
+func withCoalescing(next ChunkSource, mode CoalesceMode) ChunkSource {
+	var (
+		buf     strings.Builder
+		pending error
+	)
+	boundary := lastSentenceEnd
+	if mode == CoalesceParagraph {
+		boundary = lastParagraphEnd
+	}
+
+	return func(ctx context.Context) (Chunk, error) {
+		for pending == nil {
+			chunk, err := next(ctx)
+			if err != nil {
+				pending = err
+				break
+			}
+			if chunk.Reset {
+				buf.Reset()
+				return chunk, nil
+			}
+			buf.WriteString(chunk.Text)
+			text := buf.String()
+			if end := boundary(text); end > 0 {
+				buf.Reset()
+				buf.WriteString(text[end:])
+				return Chunk{Text: text[:end]}, nil
+			}
+		}
+
+		if buf.Len() > 0 {
+			text := buf.String()
+			buf.Reset()
+			return Chunk{Text: text}, nil
+		}
+		return Chunk{}, pending
+	}
+}
+
+// lastParagraphEnd returns the offset just past the last blank line in text,
+// or zero when there is none.
+func lastParagraphEnd(text string) int {
+	i := strings.LastIndex(text, "\n\n")
+	if i < 0 {
+		return 0
+	}
+	end := i + 2
+	for end < len(text) && text[end] == '\n' {
+		end++
+	}
+	return end
+}
+
+// lastSentenceEnd returns the offset just past the last sentence boundary in
+// text: a newline, or terminal punctuation and any closing quotes or brackets
+// followed by whitespace, which is included. It returns zero when there is
+// none; punctuation at the very end stays buffered since the next delta may
+// continue it, as in "3.14".
+func lastSentenceEnd(text string) int {
+	for i := len(text) - 1; i >= 0; i-- {
+		if text[i] == '\n' {
+			return i + 1
+		}
+		if !utf8.RuneStart(text[i]) {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch r {
+		case '。', '！', '？':
+			return i + size
+		case ' ', '\t':
+			j := i
+			for j > 0 && strings.ContainsRune(`"')]”’»`, lastRune(text[:j])) {
+				j -= utf8.RuneLen(lastRune(text[:j]))
+			}
+			if j > 0 && strings.ContainsRune(".!?", lastRune(text[:j])) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// lastRune returns the final rune of s.
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// pacingTick is the shortest interval between paced chunks, about one frame
+// at 60Hz.
+const pacingTick = time.Second / 60
+
+// withPacing splits chunks into pieces emitted at a steady rate of at most
+// charsPerSecond characters. Pieces are released once per tick, or once per
+// character when the rate is below one character per tick.
+func withPacing(next ChunkSource, charsPerSecond int) ChunkSource {
+	tick := pacingTick
+	perTick := int(float64(charsPerSecond) * tick.Seconds())
+	if perTick < 1 {
+		perTick = 1
+		tick = time.Second / time.Duration(charsPerSecond)
+	}
+
+	var (
+		rest string
+		last time.Time
+	)
+	return func(ctx context.Context) (Chunk, error) {
+		if rest == "" {
+			chunk, err := next(ctx)
+			if err != nil || chunk.Text == "" {
+				return chunk, err
+			}
+			rest = chunk.Text
+		}
+
+		if !last.IsZero() {
+			if err := sleepContext(ctx, time.Until(last.Add(tick))); err != nil {
+				return Chunk{}, err
+			}
+		}
+		last = time.Now()
+
+		cut := len(rest)
+		runes := 0
+		for i := range rest {
+			if runes == perTick {
+				cut = i
+				break
+			}
+			runes++
+		}
+		piece := rest[:cut]
+		rest = rest[cut:]
+		return Chunk{Text: piece}, nil
+	}
+}
@@ -0,0 +1,159 @@
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// replayDelta picks the streamed text out of a chat completion chunk.
+type replayDelta struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// ReplaySource turns captured output into a chunk source. When the input is an
+// SSE capture (its first non-empty line is an SSE field or comment line, such
+// as "data:", "event:", or ": keep-alive") the content deltas of every event
+// are replayed; otherwise the input is treated as plain text and emitted word
+// by word. pace is waited before each chunk.
+func ReplaySource(r io.Reader, pace time.Duration) ChunkSource {
+	reader := bufio.NewReader(r)
+	var (
+		decided bool
+		sse     bool
+		pending []string
+	)
+
+	return func(ctx context.Context) (Chunk, error) {
+		for len(pending) == 0 {
+			line, err := reader.ReadString('\n')
+			if line == "" && err != nil {
+				return Chunk{}, err
+			}
+
+			if !decided {
+				if strings.TrimSpace(line) == "" {
+					if err != nil {
+						return Chunk{}, err
+					}
+					continue
+				}
+				decided = true
+				sse = isSSELine(line)
+			}
+
+			if !sse {
+				pending = splitWords(line)
+				continue
+			}
+
+			text, done, parseErr := parseReplayLine(line)
+			if parseErr != nil {
+				return Chunk{}, parseErr
+			}
+			if done {
+				return Chunk{}, io.EOF
+			}
+			if text != "" {
+				pending = []string{text}
+			}
+		}
+
+		if err := sleepContext(ctx, pace); err != nil {
+			return Chunk{}, err
+		}
+
+		text := pending[0]
+		pending = pending[1:]
+		return Chunk{Text: text}, nil
+	}
+}
+
+// isSSELine reports whether line is an SSE comment or a data, event, id, or
+// retry field.
+func isSSELine(line string) bool {
+	if strings.HasPrefix(line, ":") {
+		return true
+	}
+	field, _, ok := strings.Cut(line, ":")
+	if !ok {
+		return false
+	}
+	switch field {
+	case "data", "event", "id", "retry":
+		return true
+	}
+	return false
+}
+
+// parseReplayLine extracts the delta text of one SSE line, reporting done on
+// the terminating [DONE] event. Lines other than data lines are ignored.
+func parseReplayLine(line string) (string, bool, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		return "", false, nil
+	}
+
+	data := bytes.TrimSpace([]byte(strings.TrimPrefix(line, "data:")))
+	if string(data) == "[DONE]" {
+		return "", true, nil
+	}
+
+	var delta replayDelta
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return "", false, fmt.Errorf("failed to decode replayed chunk: %w", err)
+	}
+	if len(delta.Choices) == 0 {
+		return "", false, nil
+	}
+	return delta.Choices[0].Delta.Content, false, nil
+}
+
+// splitWords breaks text into words that each keep their trailing whitespace.
+func splitWords(text string) []string {
+	var (
+		words []string
+		start int
+		space bool
+	)
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			words = append(words, text[start:i])
+			start = i
+			space = false
+		}
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
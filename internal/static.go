@@ -0,0 +1,26 @@
+package markdown
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// StaticSource returns a chunk source that yields chunks in order, waiting
+// delay before each one, and then io.EOF. It honors context cancellation while
+// waiting, which makes it suitable for deterministic tests of rendering and
+// interrupt handling.
+func StaticSource(chunks []string, delay time.Duration) ChunkSource {
+	remaining := append([]string(nil), chunks...)
+	return func(ctx context.Context) (Chunk, error) {
+		if len(remaining) == 0 {
+			return Chunk{}, io.EOF
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return Chunk{}, err
+		}
+		text := remaining[0]
+		remaining = remaining[1:]
+		return Chunk{Text: text}, nil
+	}
+}
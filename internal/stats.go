@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// StreamStats summarizes the performance of a completed stream.
+type StreamStats struct {
+	// TimeToFirstToken is the delay between the start of the stream and the
+	// first non-empty chunk.
+	TimeToFirstToken time.Duration
+	// Duration is the total time spent streaming.
+	Duration time.Duration
+	// Chunks counts the non-empty chunks received.
+	Chunks int
+	// Bytes counts the bytes of streamed text.
+	Bytes int
+	// Tokens is an estimate of the streamed output tokens.
+	Tokens int
+	// TokensPerSecond is the estimated generation rate after the first token.
+	TokensPerSecond float64
+	// Heartbeats counts keep-alive signals received from the producer.
+	Heartbeats int
+	// PromptTokens and CompletionTokens are the usage reported by the API,
+	// summed over every request of the stream, when the producer supplies it.
+	// They stay zero otherwise.
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// statsRecorder observes chunks as they flow through the pipeline.
+type statsRecorder struct {
+	start time.Time
+	first time.Time
+	text  strings.Builder
+	stats StreamStats
+}
+
+// newStatsRecorder starts the clock for a stream.
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{start: time.Now()}
+}
+
+// wrap counts every chunk produced by next.
+func (r *statsRecorder) wrap(next ChunkSource) ChunkSource {
+	return func(ctx context.Context) (Chunk, error) {
+		chunk, err := next(ctx)
+		if err == nil && chunk.Heartbeat {
+			r.stats.Heartbeats++
+		}
+		if err == nil && chunk.Text != "" {
+			if r.first.IsZero() {
+				r.first = time.Now()
+			}
+			r.stats.Chunks++
+			r.stats.Bytes += len(chunk.Text)
+			r.text.WriteString(chunk.Text)
+		}
+		return chunk, err
+	}
+}
+
+// finish computes the final statistics.
+func (r *statsRecorder) finish() StreamStats {
+	end := time.Now()
+	stats := r.stats
+	stats.Duration = end.Sub(r.start)
+	stats.Tokens = token.Count(r.text.String())
+	if !r.first.IsZero() {
+		stats.TimeToFirstToken = r.first.Sub(r.start)
+		if generation := end.Sub(r.first).Seconds(); generation > 0 {
+			stats.TokensPerSecond = float64(stats.Tokens) / generation
+		}
+	}
+	return stats
+}
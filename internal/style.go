@@ -0,0 +1,154 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/muesli/termenv"
+)
+
+// ColorMode controls whether the renderer emits colors.
+type ColorMode int
+
+const (
+	// ColorAuto follows NO_COLOR, CLICOLOR_FORCE, and terminal detection.
+	ColorAuto ColorMode = iota
+	// ColorAlways emits colors even when the output is not a terminal.
+	ColorAlways
+	// ColorNever renders without colors or styling.
+	ColorNever
+)
+
+// Background selects the terminal background the built-in styles target.
+type Background int
+
+const (
+	// BackgroundAuto detects the terminal background.
+	BackgroundAuto Background = iota
+	// BackgroundDark uses the style for dark backgrounds.
+	BackgroundDark
+	// BackgroundLight uses the style for light backgrounds.
+	BackgroundLight
+)
+
+// styleName returns the Glamour standard style for b, or "" when detection
+// should be used.
+func (b Background) styleName() string {
+	switch b {
+	case BackgroundDark:
+		return "dark"
+	case BackgroundLight:
+		return "light"
+	default:
+		return ""
+	}
+}
+
+// resolve applies the standard color environment variables to ColorAuto.
+// NO_COLOR (any non-empty value) disables colors and takes precedence over
+// CLICOLOR_FORCE (any non-empty value other than "0"), which forces them.
+func (m ColorMode) resolve() ColorMode {
+	if m != ColorAuto {
+		return m
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorNever
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return ColorAlways
+	}
+	return ColorAuto
+}
+
+// styleOptions returns the Glamour options selecting the style for opts. A
+// custom stylesheet replaces the standard styles, still honoring ColorNever.
+func styleOptions(opts StreamOptions) ([]glamour.TermRendererOption, error) {
+	custom, err := loadCustomStyle(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := opts.Color.resolve()
+	if custom != nil {
+		styleOpts := []glamour.TermRendererOption{glamour.WithStyles(*custom)}
+		switch mode {
+		case ColorNever:
+			styleOpts = append(styleOpts, glamour.WithColorProfile(termenv.Ascii))
+		case ColorAlways:
+			styleOpts = append(styleOpts, glamour.WithColorProfile(termenv.ANSI256))
+		}
+		return styleOpts, nil
+	}
+
+	return standardStyleOptions(mode, opts.Background), nil
+}
+
+// standardStyleOptions picks one of Glamour's built-in styles. An explicit
+// background bypasses detection, which is unreliable over SSH and tmux.
+func standardStyleOptions(mode ColorMode, bg Background) []glamour.TermRendererOption {
+	style := bg.styleName()
+	switch mode {
+	case ColorNever:
+		return []glamour.TermRendererOption{
+			glamour.WithStandardStyle("notty"),
+			glamour.WithColorProfile(termenv.Ascii),
+		}
+	case ColorAlways:
+		if style == "" {
+			style = "light"
+			if termenv.HasDarkBackground() {
+				style = "dark"
+			}
+		}
+		return []glamour.TermRendererOption{
+			glamour.WithStandardStyle(style),
+			glamour.WithColorProfile(termenv.ANSI256),
+		}
+	default:
+		if style != "" {
+			return []glamour.TermRendererOption{glamour.WithStandardStyle(style)}
+		}
+		return []glamour.TermRendererOption{glamour.WithAutoStyle()}
+	}
+}
+
+// ValidateOptions checks the parts of opts that can fail before any request is
+// made, such as a custom style sheet.
+func ValidateOptions(opts StreamOptions) error {
+	if opts.Raw {
+		return nil
+	}
+	_, err := loadCustomStyle(opts)
+	return err
+}
+
+// loadCustomStyle reads and validates the stylesheet configured in opts,
+// returning nil when none is set. StyleJSON takes precedence over StylePath.
+// Unknown keys are rejected so typos in a theme surface at startup.
+func loadCustomStyle(opts StreamOptions) (*ansi.StyleConfig, error) {
+	data := opts.StyleJSON
+	source := "StyleJSON"
+	if len(data) == 0 && opts.StylePath != "" {
+		var err error
+		data, err = os.ReadFile(opts.StylePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read markdown style: %w", err)
+		}
+		source = opts.StylePath
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var style ansi.StyleConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&style); err != nil {
+		return nil, fmt.Errorf("invalid markdown style %s: %w", source, err)
+	}
+	return &style, nil
+}
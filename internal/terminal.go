@@ -0,0 +1,32 @@
+package markdown
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// isTerminal reports whether w is a file attached to a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// resolveUIWriter picks where the interactive viewport and loader are drawn.
+// An explicit UIWriter always wins. Otherwise stderr is preferred when it is a
+// terminal, keeping stdout for content, and the content writer is used only
+// when it is a terminal itself, so control sequences never end up in files or
+// pipes. It returns nil when no terminal is available.
+func resolveUIWriter(w io.Writer, opts StreamOptions) io.Writer {
+	if opts.UIWriter != nil {
+		return opts.UIWriter
+	}
+	if isTerminal(os.Stderr) {
+		return os.Stderr
+	}
+	if isTerminal(w) {
+		return w
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyExporter receives latency observations from the client. Endpoint
+// keys have the form "POST /chat/completions". Implementations must be safe
+// for concurrent use.
+type LatencyExporter interface {
+	// ObserveLatency records the time until response headers arrived
+	ObserveLatency(endpoint string, d time.Duration)
+	// ObserveTimeToFirstToken records the time until the first streamed token
+	ObserveTimeToFirstToken(endpoint string, d time.Duration)
+}
+
+// WithLatencyExporter reports per-endpoint request latency and stream
+// time-to-first-token to e
+func WithLatencyExporter(e LatencyExporter) ClientOption {
+	return func(c *Client) {
+		c.latency = e
+	}
+}
+
+// defaultLatencyBuckets are the histogram upper bounds, from 50ms to ~100s
+var defaultLatencyBuckets = func() []time.Duration {
+	buckets := make([]time.Duration, 0, 12)
+	for d := 50 * time.Millisecond; d <= 2*time.Minute; d *= 2 {
+		buckets = append(buckets, d)
+	}
+	return buckets
+}()
+
+// LatencyHistogram is a LatencyExporter that aggregates observations into
+// fixed buckets per endpoint
+type LatencyHistogram struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	latency map[string]*histogram
+	ttft    map[string]*histogram
+}
+
+// NewLatencyHistogram creates a histogram with the given bucket upper bounds,
+// or exponential buckets from 50ms to about 100s when none are given
+func NewLatencyHistogram(buckets ...time.Duration) *LatencyHistogram {
+	if len(buckets) == 0 {
+		buckets = defaultLatencyBuckets
+	}
+	sorted := append([]time.Duration(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &LatencyHistogram{
+		buckets: sorted,
+		latency: make(map[string]*histogram),
+		ttft:    make(map[string]*histogram),
+	}
+}
+
+// ObserveLatency implements LatencyExporter
+func (h *LatencyHistogram) ObserveLatency(endpoint string, d time.Duration) {
+	h.observe(h.latency, endpoint, d)
+}
+
+// ObserveTimeToFirstToken implements LatencyExporter
+func (h *LatencyHistogram) ObserveTimeToFirstToken(endpoint string, d time.Duration) {
+	h.observe(h.ttft, endpoint, d)
+}
+
+func (h *LatencyHistogram) observe(into map[string]*histogram, endpoint string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := into[endpoint]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets)+1)}
+		into[endpoint] = hist
+	}
+	hist.add(h.buckets, d)
+}
+
+// Latency returns a snapshot of the header latency histograms per endpoint
+func (h *LatencyHistogram) Latency() map[string]HistogramSnapshot {
+	return h.snapshot(h.latency)
+}
+
+// TimeToFirstToken returns a snapshot of the stream TTFT histograms per endpoint
+func (h *LatencyHistogram) TimeToFirstToken() map[string]HistogramSnapshot {
+	return h.snapshot(h.ttft)
+}
+
+func (h *LatencyHistogram) snapshot(from map[string]*histogram) map[string]HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string]HistogramSnapshot, len(from))
+	for endpoint, hist := range from {
+		out[endpoint] = hist.snapshot(h.buckets)
+	}
+	return out
+}
+
+// histogram holds bucket counts; the last count is the overflow bucket
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func (h *histogram) add(bounds []time.Duration, d time.Duration) {
+	idx := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] })
+	h.counts[idx]++
+	h.count++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+func (h *histogram) snapshot(bounds []time.Duration) HistogramSnapshot {
+	snap := HistogramSnapshot{
+		Count:   h.count,
+		Sum:     h.sum,
+		Max:     h.max,
+		Buckets: make([]HistogramBucket, len(h.counts)),
+	}
+	for i, n := range h.counts {
+		upper := time.Duration(math.MaxInt64)
+		if i < len(bounds) {
+			upper = bounds[i]
+		}
+		snap.Buckets[i] = HistogramBucket{UpperBound: upper, Count: n}
+	}
+	return snap
+}
+
+// HistogramBucket counts observations up to and including UpperBound that did
+// not fall into a lower bucket
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// HistogramSnapshot is a point-in-time copy of one endpoint's histogram
+type HistogramSnapshot struct {
+	Count   uint64
+	Sum     time.Duration
+	Max     time.Duration
+	Buckets []HistogramBucket
+}
+
+// Mean returns the average observation
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile estimates the q-quantile (0..1) as the upper bound of the bucket
+// containing it, capped at the largest observation
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(s.Count)))
+	var seen uint64
+	for _, b := range s.Buckets {
+		seen += b.Count
+		if seen >= rank {
+			return min(b.UpperBound, s.Max)
+		}
+	}
+	return s.Max
+}
@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLogBodyBytes bounds how much of a request or response body is logged
+const maxLogBodyBytes = 2 << 10
+
+// redactedHeaders are logged as "[REDACTED]"
+var redactedHeaders = []string{"Authorization", "Api-Key", "Cookie", "Set-Cookie"}
+
+// logRedactor masks API keys that appear in logged bodies
+var logRedactor = NewRedactor(APIKeyRule)
+
+// WithLogger logs every request at debug level: the method, path, headers
+// with credentials redacted, and truncated body when it is sent, and the
+// status, request ID, latency, and truncated body once the response has been
+// read. Streams are logged when they end, so the latency covers the whole
+// stream. Bodies are scrubbed of API keys and by the WithRequestRedactor
+// redactor, and headers set by a RequestSigner are redacted. Nothing is
+// logged unless the logger's handler enables slog.LevelDebug.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// requestLog collects the attributes logged for one request
+type requestLog struct {
+	logger *slog.Logger
+	ctx    context.Context
+	attrs  []slog.Attr
+	start  time.Time
+	// redactor, when set, scrubs bodies after logRedactor
+	redactor *Redactor
+	// signed names the headers a RequestSigner set or changed
+	signed []string
+}
+
+// startRequestLog returns nil when debug logging is off
+func (c *Client) startRequestLog(ctx context.Context, correlationID, method, path string) *requestLog {
+	if c.logger == nil || !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return nil
+	}
+	return &requestLog{
+		logger:   c.logger,
+		ctx:      ctx,
+		redactor: c.redactor,
+		attrs: []slog.Attr{
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.String("correlation_id", correlationID),
+		},
+	}
+}
+
+// sent logs the outgoing request and starts the latency clock
+func (l *requestLog) sent(req *http.Request, body string) {
+	l.start = time.Now()
+	l.logger.LogAttrs(l.ctx, slog.LevelDebug, "openai request",
+		append(l.attrs,
+			slog.Any("headers", logHeaders(req.Header, l.signed)),
+			slog.String("body", l.body(body, len(body))),
+		)...,
+	)
+}
+
+// failed logs a request that got no response
+func (l *requestLog) failed(err error) {
+	l.logger.LogAttrs(l.ctx, slog.LevelDebug, "openai request failed",
+		append(l.attrs,
+			slog.Duration("latency", time.Since(l.start)),
+			slog.String("error", err.Error()),
+		)...,
+	)
+}
+
+// received logs a response whose body has been read
+func (l *requestLog) received(resp *http.Response, body string, size int) {
+	l.logger.LogAttrs(l.ctx, slog.LevelDebug, "openai response",
+		append(l.attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("request_id", resp.Header.Get("X-Request-Id")),
+			slog.Duration("latency", time.Since(l.start)),
+			slog.String("body", l.body(body, size)),
+		)...,
+	)
+}
+
+// wrap delays the response log until resp.Body is closed
+func (l *requestLog) wrap(resp *http.Response) io.ReadCloser {
+	b := &loggedBody{ReadCloser: resp.Body, log: l, resp: resp}
+	if !auditable(resp.Header.Get("Content-Type")) {
+		b.omitted = "[" + resp.Header.Get("Content-Type") + " body omitted]"
+	}
+	return b
+}
+
+// loggedBody keeps the start of a response body for the log
+type loggedBody struct {
+	io.ReadCloser
+	log     *requestLog
+	resp    *http.Response
+	omitted string
+
+	// mu guards head and size, since streams may be closed while being read
+	mu   sync.Mutex
+	head strings.Builder
+	size int
+	once sync.Once
+}
+
+// Read implements io.Reader
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if room := maxLogBodyBytes + 1 - b.head.Len(); room > 0 && b.omitted == "" {
+		b.head.Write(p[:min(n, room)])
+	}
+	b.size += n
+	b.mu.Unlock()
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.mu.Lock()
+		body, size := b.head.String(), b.size
+		b.mu.Unlock()
+		if b.omitted != "" {
+			body, size = b.omitted, len(b.omitted)
+		}
+		b.log.received(b.resp, body, size)
+	})
+	return err
+}
+
+// signedBy records the headers of req that differ from unsigned, its headers
+// before signing, so they are redacted
+func (l *requestLog) signedBy(unsigned http.Header, req *http.Request) {
+	for name, values := range req.Header {
+		if !slices.Equal(unsigned[name], values) {
+			l.signed = append(l.signed, name)
+		}
+	}
+}
+
+// logHeaders copies h with credentials and the signed headers redacted
+func logHeaders(h http.Header, signed []string) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		headers[name] = strings.Join(values, ", ")
+	}
+	for _, name := range slices.Concat(redactedHeaders, signed) {
+		if _, ok := headers[name]; ok {
+			headers[name] = "[REDACTED]"
+		}
+	}
+	return headers
+}
+
+// body redacts body and truncates it to maxLogBodyBytes, noting the full size
+// when anything was cut
+func (l *requestLog) body(body string, size int) string {
+	truncated := size > len(body) || len(body) > maxLogBodyBytes
+	if len(body) > maxLogBodyBytes {
+		body = strings.ToValidUTF8(body[:maxLogBodyBytes], "")
+	}
+	body = logRedactor.Redact(body)
+	if l.redactor != nil {
+		body = l.redactor.Redact(body)
+	}
+	if truncated {
+		body += fmt.Sprintf("... (%d bytes)", size)
+	}
+	return body
+}
@@ -1,6 +1,118 @@
 package openai
 
-import markdown "github.com/jiyeol-lee/openai/internal"
+import (
+	"context"
+	"io"
+	"time"
+
+	markdown "github.com/jiyeol-lee/openai/internal"
+)
 
 // StreamOptions configures markdown streaming output for CreateChatCompletionStreamWithMarkdown.
 type StreamOptions = markdown.StreamOptions
+
+// ColorMode controls whether markdown output is colored.
+type ColorMode = markdown.ColorMode
+
+const (
+	// ColorAuto follows NO_COLOR, CLICOLOR_FORCE, and terminal detection.
+	ColorAuto = markdown.ColorAuto
+	// ColorAlways emits colors even when the output is not a terminal.
+	ColorAlways = markdown.ColorAlways
+	// ColorNever renders without colors or styling.
+	ColorNever = markdown.ColorNever
+)
+
+// Background selects the terminal background targeted by the built-in styles.
+type Background = markdown.Background
+
+const (
+	// BackgroundAuto detects the terminal background.
+	BackgroundAuto = markdown.BackgroundAuto
+	// BackgroundDark uses the style for dark backgrounds.
+	BackgroundDark = markdown.BackgroundDark
+	// BackgroundLight uses the style for light backgrounds.
+	BackgroundLight = markdown.BackgroundLight
+)
+
+// CoalesceMode selects the boundaries at which StreamOptions.Coalesce emits
+// buffered deltas.
+type CoalesceMode = markdown.CoalesceMode
+
+const (
+	// CoalesceNone emits every delta as it arrives.
+	CoalesceNone = markdown.CoalesceNone
+	// CoalesceSentence emits complete sentences and lines.
+	CoalesceSentence = markdown.CoalesceSentence
+	// CoalesceParagraph emits complete paragraphs.
+	CoalesceParagraph = markdown.CoalesceParagraph
+)
+
+// StreamStats reports timing and throughput of a completed markdown stream.
+type StreamStats = markdown.StreamStats
+
+// Chunk is an incremental markdown fragment produced by a ChunkSource.
+type Chunk = markdown.Chunk
+
+// ChunkSource produces the next chunk of a stream, returning io.EOF once the
+// stream is complete.
+type ChunkSource = markdown.ChunkSource
+
+// StreamMarkdown renders chunks from next to w using the same terminal
+// renderer as CreateChatCompletionStreamWithMarkdown.
+func StreamMarkdown(ctx context.Context, next ChunkSource, w io.Writer, opts StreamOptions) error {
+	return markdown.StreamMarkdown(ctx, next, w, opts)
+}
+
+// ReplaySource turns a captured SSE stream (see WithSSECapture) or plain text
+// into a ChunkSource, waiting pace before each chunk.
+func ReplaySource(r io.Reader, pace time.Duration) ChunkSource {
+	return markdown.ReplaySource(r, pace)
+}
+
+// StaticSource returns a ChunkSource that yields chunks in order, waiting
+// delay before each one, for deterministic tests of code embedding the
+// markdown renderer.
+func StaticSource(chunks []string, delay time.Duration) ChunkSource {
+	return markdown.StaticSource(chunks, delay)
+}
+
+// ErrChunkTimeout is returned when no chunk arrives within
+// StreamOptions.ChunkTimeout.
+var ErrChunkTimeout = markdown.ErrChunkTimeout
+
+// InterruptedError is returned by markdown streaming when the user presses
+// Ctrl+C or the context ends the stream early. It carries the partial content
+// and viewport state and unwraps to the cancellation error.
+type InterruptedError = markdown.InterruptedError
+
+// InterruptReason tells why a markdown stream was interrupted.
+type InterruptReason = markdown.InterruptReason
+
+const (
+	// InterruptUser means the user pressed Ctrl+C in the viewport.
+	InterruptUser = markdown.InterruptUser
+	// InterruptContext means the caller's context was canceled or expired.
+	InterruptContext = markdown.InterruptContext
+)
+
+// Turn is a prior conversation turn shown above the streaming answer through
+// StreamOptions.History.
+type Turn = markdown.Turn
+
+// HistoryFromMessages converts chat messages into turns for
+// StreamOptions.History, skipping messages without text.
+func HistoryFromMessages(messages []Message) []Turn {
+	turns := make([]Turn, 0, len(messages))
+	for _, m := range messages {
+		content := m.Content
+		if len(m.Parts) > 0 {
+			content = m.Parts.Text()
+		}
+		if content == "" {
+			continue
+		}
+		turns = append(turns, Turn{Role: m.Role, Content: content})
+	}
+	return turns
+}
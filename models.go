@@ -0,0 +1,44 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Model describes a model available to the API key
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	// OwnedBy is the organization owning the model, such as "openai" or,
+	// for fine-tuned models, the caller's organization
+	OwnedBy string `json:"owned_by"`
+}
+
+// ListModels lists the models available to the API key
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	var list struct {
+		Data []Model `json:"data"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/models", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+// GetModel retrieves a model, for validating a configured model ID before
+// use. An unknown ID fails with an *APIError that ClassifyError reports as
+// ErrorKindModelNotFound.
+func (c *Client) GetModel(ctx context.Context, id string) (*Model, error) {
+	var model Model
+	if err := c.Do(ctx, http.MethodGet, "/models/"+url.PathEscape(id), nil, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// DeleteModel deletes a fine-tuned model owned by the caller's organization
+func (c *Client) DeleteModel(ctx context.Context, id string) error {
+	return c.Do(ctx, http.MethodDelete, "/models/"+url.PathEscape(id), nil, nil)
+}
@@ -0,0 +1,107 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// formBuilder builds a multipart request body, keeping the first error so
+// fields can be added without checking each one
+type formBuilder struct {
+	body bytes.Buffer
+	form *multipart.Writer
+	err  error
+}
+
+// newFormBuilder returns an empty form
+func newFormBuilder() *formBuilder {
+	f := &formBuilder{}
+	f.form = multipart.NewWriter(&f.body)
+	return f
+}
+
+// field adds a text field, skipping empty values
+func (f *formBuilder) field(name, value string) {
+	if f.err != nil || value == "" {
+		return
+	}
+	if err := f.form.WriteField(name, value); err != nil {
+		f.err = fmt.Errorf("failed to build upload: %w", err)
+	}
+}
+
+// intField adds a numeric field, skipping zero
+func (f *formBuilder) intField(name string, value int) {
+	if value != 0 {
+		f.field(name, strconv.Itoa(value))
+	}
+}
+
+// file adds a file field with the content of r, typed by the extension of
+// filename, since some endpoints reject application/octet-stream
+func (f *formBuilder) file(name, filename string, r io.Reader) {
+	if f.err != nil {
+		return
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(name), escapeQuotes(filename)))
+	header.Set("Content-Type", contentType)
+	part, err := f.form.CreatePart(header)
+	if err != nil {
+		f.err = fmt.Errorf("failed to build upload: %w", err)
+		return
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		f.err = fmt.Errorf("failed to read upload: %w", err)
+	}
+}
+
+// finish closes the form and returns its content type and body
+func (f *formBuilder) finish() (string, *bytes.Buffer, error) {
+	if f.err != nil {
+		return "", nil, f.err
+	}
+	if err := f.form.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to build upload: %w", err)
+	}
+	return f.form.FormDataContentType(), &f.body, nil
+}
+
+// postForm sends form to path and decodes the response into out
+func (c *Client) postForm(ctx context.Context, path string, form *formBuilder, out any) error {
+	contentType, body, err := form.finish()
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRequestWithType(ctx, http.MethodPost, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if err := c.decodeResponse(resp.Body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// quoteEscaper escapes a form field or file name as mime/multipart does
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes s for a quoted Content-Disposition parameter
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
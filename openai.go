@@ -4,77 +4,467 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const baseURL = "https://api.openai.com/v1"
+// defaultBaseURL is the endpoint of the OpenAI API
+const defaultBaseURL = "https://api.openai.com/v1"
 
-// Client handles OpenAI API requests
+// ErrInvalidBaseURL is returned by every call of a client configured with an
+// unusable WithBaseURL value
+var ErrInvalidBaseURL = errors.New("invalid base URL")
+
+// Client handles OpenAI API requests. A Client is safe for concurrent use by
+// multiple goroutines and should be reused. Options are applied once by
+// NewClient, which copies the HTTP client, maps, and slices they receive, so
+// later changes by the caller do not affect the client.
 type Client struct {
-	httpClient *http.Client
-	apiKey     string
+	httpClient    *http.Client
+	streamClient  *http.Client
+	streamTimeout time.Duration
+	baseURL       string
+	baseURLErr    error
+	tokens        TokenProvider
+	autoContinue  int
+	postProcess   PostProcess
+	capture       *sseCapture
+	latency       LatencyExporter
+	budget        *budgetTracker
+	maxRetries    int
+	strict        bool
+	strictStreams bool
+	betaFeatures  []string
+	apiVersion    string
+	organization  string
+	project       string
+	limiter       *rateLimiter
+	usage         *usageCounter
+	lifecycle     lifecycle
+	concurrency   *concurrencyController
+	compat        *compatShims
+	audit         *auditLog
+	redactor      *Redactor
+	stopEmulation bool
+	idempotency   bool
+	rateLimit     atomic.Pointer[RateLimitInfo]
+	logger        *slog.Logger
+	signer        RequestSigner
+	transport     *TransportConfig
 }
 
 // ClientOption is a functional option for configuring the Client
 type ClientOption func(*Client)
 
-// WithHTTPClient sets a custom HTTP client
+// WithHTTPClient sets a custom HTTP client. The client is copied, not
+// modified. An *http.Transport is cloned for every host the client talks to,
+// keeping its settings; other round trippers are used as they are.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
 		c.httpClient = httpClient
 	}
 }
 
+// WithAutoContinue re-issues requests whose answer was truncated by the token
+// limit (finish_reason "length") up to maxContinuations times, asking the model
+// to continue from the partial answer and stitching the parts together. It
+// applies to CreateChatCompletion and CreateChatCompletionStreamWithMarkdown.
+func WithAutoContinue(maxContinuations int) ClientOption {
+	return func(c *Client) {
+		c.autoContinue = maxContinuations
+	}
+}
+
+// WithBaseURL sends requests to an OpenAI-compatible server, proxy, or gateway
+// instead of api.openai.com. The URL must be absolute http or https without a
+// query or fragment, such as "http://localhost:8080/v1"; otherwise every call
+// fails with ErrInvalidBaseURL.
+func WithBaseURL(rawURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL, c.baseURLErr = parseBaseURL(rawURL)
+	}
+}
+
+// parseBaseURL validates rawURL and strips its trailing slash
+func parseBaseURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidBaseURL, err)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return "", fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidBaseURL, rawURL)
+	case u.Host == "":
+		return "", fmt.Errorf("%w %q: missing host", ErrInvalidBaseURL, rawURL)
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", fmt.Errorf("%w %q: must not have a query or fragment", ErrInvalidBaseURL, rawURL)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// WithBetaFeatures opts every request into beta API features such as
+// "assistants=v2" through the OpenAI-Beta header. Repeated use accumulates.
+func WithBetaFeatures(features ...string) ClientOption {
+	return func(c *Client) {
+		c.betaFeatures = append(c.betaFeatures, features...)
+	}
+}
+
+// betaKey carries the beta feature required by the endpoint of a request
+type betaKey struct{}
+
+// withBeta opts the requests made with ctx into feature, for endpoints that
+// only exist behind a beta header
+func withBeta(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, betaKey{}, feature)
+}
+
+// betaFeaturesFor returns the OpenAI-Beta features of a request made with ctx
+func (c *Client) betaFeaturesFor(ctx context.Context) []string {
+	feature, _ := ctx.Value(betaKey{}).(string)
+	if feature == "" || slices.Contains(c.betaFeatures, feature) {
+		return c.betaFeatures
+	}
+	return append(slices.Clip(c.betaFeatures), feature)
+}
+
+// WithAPIVersion pins the API version of every request with the api-version
+// query parameter, as required by Azure OpenAI deployments.
+func WithAPIVersion(version string) ClientOption {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// WithOrganization sends requests on behalf of an organization through the
+// OpenAI-Organization header
+func WithOrganization(org string) ClientOption {
+	return func(c *Client) {
+		c.organization = org
+	}
+}
+
+// WithProject attributes requests to a project through the OpenAI-Project
+// header
+func WithProject(project string) ClientOption {
+	return func(c *Client) {
+		c.project = project
+	}
+}
+
 // NewClient creates a new OpenAI client
 func NewClient(apiKey string, opts ...ClientOption) *Client {
 	c := &Client{
-		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		tokens:        staticToken(apiKey),
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		baseURL:       defaultBaseURL,
+		streamTimeout: defaultStreamTimeout,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	httpClient := *c.httpClient
+	if c.transport != nil {
+		httpClient.Transport = c.transport.apply(httpClient.Transport)
+	}
+	if hosts := newHostTransports(httpClient.Transport); hosts != nil {
+		httpClient.Transport = hosts
+	}
+	if c.maxRetries > 0 {
+		httpClient.Transport = &RetryTransport{
+			Base:       httpClient.Transport,
+			MaxRetries: c.maxRetries,
+		}
+	}
+	c.httpClient = &httpClient
+	streamClient := httpClient
+	streamClient.Timeout = 0
+	c.streamClient = &streamClient
+
 	return c
 }
 
-// doRequest performs an HTTP request with proper headers
+// doRequest performs an HTTP request with a JSON body and proper headers
 func (c *Client) doRequest(
 	ctx context.Context,
 	method, path string,
 	body io.Reader,
 ) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	return c.doRequestWithType(ctx, method, path, "application/json", body)
+}
+
+// doRequestWithType performs an HTTP request whose body has the given content
+// type, such as a multipart upload. The call stays in flight for Close until
+// the response body is closed.
+func (c *Client) doRequestWithType(
+	ctx context.Context,
+	method, path, contentType string,
+	body io.Reader,
+) (*http.Response, error) {
+	if err := c.lifecycle.begin(); err != nil {
+		return nil, err
+	}
+	resp, err := c.admitRequest(ctx, method, path, contentType, body)
+	if err != nil {
+		c.lifecycle.end()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: c.lifecycle.end}
+	return resp, nil
+}
+
+// admitRequest checks the budget and rate limit before sending a request
+func (c *Client) admitRequest(
+	ctx context.Context,
+	method, path, contentType string,
+	body io.Reader,
+) (*http.Response, error) {
+	if c.budget != nil {
+		if err := c.budget.check(0); err != nil {
+			return nil, err
+		}
+	}
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if c.usage != nil {
+		c.usage.requests.Add(1)
+	}
+
+	id := correlationIDFor(ctx)
+	resp, err := c.sendRequest(ctx, id, method, path, contentType, body)
+	return resp, withCorrelation(id, err)
+}
+
+// sendRequest builds, sends, and checks a single request tagged with the
+// correlation ID
+func (c *Client) sendRequest(
+	ctx context.Context,
+	correlationID, method, path, contentType string,
+	body io.Reader,
+) (*http.Response, error) {
+	baseURL, err := c.baseURLFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.startRequestLog(ctx, correlationID, method, path)
+	var requestBody string
+	if c.audit != nil || log != nil {
+		var err error
+		if requestBody, body, err = auditRequestBody(contentType, body); err != nil {
+			return nil, err
+		}
+	}
+	var record AuditRecord
+	if c.audit != nil {
+		record = AuditRecord{
+			Time:          time.Now(),
+			CorrelationID: correlationID,
+			Method:        method,
+			Path:          path,
+			Request:       requestBody,
+		}
+	}
+
+	httpClient, reqCtx := c.httpClient, ctx
+	var idle *idleTimer
+	if isStreaming(ctx) {
+		httpClient = c.streamClient
+		if c.streamTimeout > 0 {
+			reqCtx, idle = withIdleTimeout(ctx, c.streamTimeout)
+			defer func() {
+				if idle != nil {
+					idle.stop()
+				}
+			}()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, method, baseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+	setAcceptEncoding(ctx, req)
+	req.Header.Set(CorrelationHeader, correlationID)
+	if key := c.idempotencyKeyFor(ctx, method); key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	if c.organization != "" {
+		req.Header.Set("OpenAI-Organization", c.organization)
+	}
+	if c.project != "" {
+		req.Header.Set("OpenAI-Project", c.project)
+	}
+	if features := c.betaFeaturesFor(ctx); len(features) > 0 {
+		req.Header.Set("OpenAI-Beta", strings.Join(features, ","))
+	}
+	if c.apiVersion != "" {
+		query := req.URL.Query()
+		query.Set("api-version", c.apiVersion)
+		req.URL.RawQuery = query.Encode()
+	}
+
+	var bodyHoldsSlot bool
+	if c.concurrency != nil {
+		// The slot is held until the body is closed, so streams and large
+		// bodies count against the limit while they are read.
+		if err := c.concurrency.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !bodyHoldsSlot {
+				c.concurrency.release()
+			}
+		}()
+	}
 
-	resp, err := c.httpClient.Do(req)
+	if c.signer != nil {
+		var unsigned http.Header
+		if log != nil {
+			unsigned = req.Header.Clone()
+		}
+		if err := c.signer.SignRequest(req); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		if log != nil {
+			log.signedBy(unsigned, req)
+		}
+	}
+	if log != nil {
+		log.sent(req, requestBody)
+	}
+	start := time.Now()
+	if idle != nil {
+		idle.start()
+	}
+	resp, err := httpClient.Do(req)
+	if err == nil && c.concurrency != nil {
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: c.concurrency.release}
+		bodyHoldsSlot = true
+	}
+	if idle != nil && err != nil {
+		err = idle.sendError(err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		err = fmt.Errorf("failed to send request: %w", classifySendError(err))
+		if log != nil {
+			log.failed(err)
+		}
+		if c.audit != nil {
+			record.Duration = time.Since(start)
+			record.Error = err.Error()
+			c.audit.write(ctx, record)
+		}
+		return nil, err
+	}
+	if idle != nil {
+		// The body stops the timer from now on.
+		resp.Body = idle.wrap(resp.Body)
+		idle = nil
+	}
+	decompressResponse(resp)
+	c.observeRateLimit(resp)
+	if c.concurrency != nil {
+		c.concurrency.observe(resp)
+	}
+	if c.latency != nil {
+		c.latency.ObserveLatency(method+" "+path, time.Since(start))
+	}
+
+	if c.audit != nil {
+		record.StatusCode = resp.StatusCode
+		record.Stream = strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
 	}
 
 	if resp.StatusCode >= 300 {
 		data, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf(
-			"API error (status %s): %s",
-			resp.Status,
-			strings.TrimSpace(string(data)),
-		)
+		err := newResponseError(resp, data)
+		if log != nil {
+			log.received(resp, string(data), len(data))
+		}
+		if c.audit != nil {
+			record.Duration = time.Since(start)
+			record.Response = string(data)
+			record.Error = err.Error()
+			c.audit.write(ctx, record)
+		}
+		return nil, err
 	}
 
+	if c.audit != nil {
+		resp.Body = newAuditBody(ctx, c.audit, record, start, resp)
+	}
+	if log != nil {
+		resp.Body = log.wrap(resp)
+	}
 	return resp, nil
 }
 
+// recordUsage accounts for the tokens reported by a completed call
+func (c *Client) recordUsage(model string, promptTokens, completionTokens int) {
+	if c.budget != nil {
+		c.budget.record(c.budget.cost(model, promptTokens, completionTokens))
+	}
+	if c.usage != nil {
+		c.usage.promptTokens.Add(int64(promptTokens))
+		c.usage.completionTokens.Add(int64(completionTokens))
+	}
+}
+
+const (
+	// maxDrainBytes bounds how much of an unread body is discarded on close
+	maxDrainBytes = 64 << 10
+	// maxDrainTime bounds how long closing waits for a live body to drain
+	maxDrainTime = 100 * time.Millisecond
+)
+
+// releaseBody calls release once the body is closed, ending what was held
+// for the response, such as a concurrency slot
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close implements io.Closer
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// drainAndClose discards up to maxDrainBytes of body within maxDrainTime and
+// closes it. Bodies read to EOF let net/http return the connection to the idle
+// pool; bodies that are still streaming are cut off when the timer fires.
+func drainAndClose(body io.ReadCloser) error {
+	timer := time.AfterFunc(maxDrainTime, func() { body.Close() })
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	timer.Stop()
+	return body.Close()
+}
+
 // marshalRequest marshals a request body to JSON
 func marshalRequest(v any) (io.Reader, error) {
 	bodyBytes, err := json.Marshal(v)
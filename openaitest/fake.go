@@ -0,0 +1,81 @@
+package openaitest
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/jiyeol-lee/openai"
+)
+
+// Fake is an in-memory openai.ChatClient that answers from rules like a canned
+// client, with synthetic SSE streams for streaming calls, and records every
+// request for assertions. It is safe for concurrent use.
+type Fake struct {
+	client *openai.Client
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+}
+
+var _ openai.ChatClient = (*Fake)(nil)
+
+// NewFake creates a fake answering with the first matching rule. Unmatched
+// requests fail with a 404 *openai.APIError.
+func NewFake(rules ...Rule) *Fake {
+	return &Fake{client: NewCannedClient(rules)}
+}
+
+// Requests returns the requests received so far, in order
+func (f *Fake) Requests() []openai.ChatCompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.requests)
+}
+
+// record stores req
+func (f *Fake) record(req openai.ChatCompletionRequest) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+}
+
+// CreateChatCompletion implements openai.ChatClient
+func (f *Fake) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (string, error) {
+	f.record(req)
+	return f.client.CreateChatCompletion(ctx, req)
+}
+
+// CreateChatCompletionFull implements openai.ChatClient
+func (f *Fake) CreateChatCompletionFull(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	f.record(req)
+	return f.client.CreateChatCompletionFull(ctx, req)
+}
+
+// CreateChatCompletionStream implements openai.ChatClient
+func (f *Fake) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.StreamReader, error) {
+	f.record(req)
+	return f.client.CreateChatCompletionStream(ctx, req)
+}
+
+// CreateChatCompletionStreamWithMarkdown implements openai.ChatClient
+func (f *Fake) CreateChatCompletionStreamWithMarkdown(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+	w io.Writer,
+	opts openai.StreamOptions,
+) error {
+	f.record(req)
+	return f.client.CreateChatCompletionStreamWithMarkdown(ctx, req, w, opts)
+}
+
+// NewStream returns a stream yielding chunks as content deltas, followed by a
+// chunk with finish reason "stop", for hand-written openai.ChatClient doubles
+func NewStream(chunks ...string) *openai.StreamReader {
+	if len(chunks) == 0 {
+		chunks = []string{""}
+	}
+	body := streamBody(context.Background(), "chatcmpl-canned-stream", "", Rule{Chunks: chunks})
+	return openai.NewStreamReader(body)
+}
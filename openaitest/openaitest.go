@@ -0,0 +1,251 @@
+// Package openaitest provides offline clients for tests. A canned client
+// answers chat completion requests from predefined rules, with optional
+// latency, so applications can exercise their full request path without
+// network access or API costs. Fake does the same behind the openai.ChatClient
+// interface and records the requests it receives.
+package openaitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiyeol-lee/openai"
+)
+
+// Rule answers the chat completion requests it matches
+type Rule struct {
+	// Match selects the requests the rule answers; nil matches every request.
+	// Rules are tried in order.
+	Match func(openai.ChatCompletionRequest) bool
+	// Reply is the assistant message content
+	Reply string
+	// Chunks are the streamed content deltas; by default Reply is split
+	// after each space
+	Chunks []string
+	// FinishReason defaults to "stop"
+	FinishReason string
+	// StatusCode and Body, when StatusCode is set, replace the completion
+	// with an error response, for testing error handling
+	StatusCode int
+	Body       string
+	// Latency delays the response headers and ChunkDelay each streamed chunk
+	Latency    time.Duration
+	ChunkDelay time.Duration
+}
+
+// ModelIs matches requests for model
+func ModelIs(model string) func(openai.ChatCompletionRequest) bool {
+	return func(req openai.ChatCompletionRequest) bool {
+		return req.Model == model
+	}
+}
+
+// LastMessageContains matches requests whose last message contains substr
+func LastMessageContains(substr string) func(openai.ChatCompletionRequest) bool {
+	return func(req openai.ChatCompletionRequest) bool {
+		if len(req.Messages) == 0 {
+			return false
+		}
+		last := req.Messages[len(req.Messages)-1]
+		return strings.Contains(last.Content, substr) || strings.Contains(last.Parts.Text(), substr)
+	}
+}
+
+// NewCannedClient creates a client whose chat completions, streaming or not,
+// are answered by the first matching rule. Unmatched requests and other
+// endpoints fail with a 404 API error. opts are applied after the canned
+// transport is installed.
+func NewCannedClient(rules []Rule, opts ...openai.ClientOption) *openai.Client {
+	transport := &cannedTransport{rules: rules}
+	opts = append([]openai.ClientOption{
+		openai.WithHTTPClient(&http.Client{Transport: transport}),
+	}, opts...)
+	return openai.NewClient("test", opts...)
+}
+
+// cannedTransport serves canned responses in place of the network
+type cannedTransport struct {
+	rules []Rule
+	mu    sync.Mutex
+	n     int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cannedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		return errorResponse(r, http.StatusNotFound, "openaitest: no canned response for "+r.Method+" "+r.URL.Path), nil
+	}
+
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errorResponse(r, http.StatusBadRequest, "openaitest: invalid request body: "+err.Error()), nil
+	}
+
+	rule, ok := t.match(req)
+	if !ok {
+		return errorResponse(r, http.StatusNotFound, "openaitest: no canned rule matches the request"), nil
+	}
+
+	if err := sleep(r.Context(), rule.Latency); err != nil {
+		return nil, err
+	}
+	if rule.StatusCode != 0 {
+		return response(r, rule.StatusCode, "application/json", io.NopCloser(strings.NewReader(rule.Body))), nil
+	}
+
+	id := t.nextID()
+	if req.Stream {
+		return response(r, http.StatusOK, "text/event-stream", streamBody(r.Context(), id, req.Model, rule)), nil
+	}
+	data, err := json.Marshal(completion(id, req.Model, rule))
+	if err != nil {
+		return nil, err
+	}
+	return response(r, http.StatusOK, "application/json", io.NopCloser(bytes.NewReader(data))), nil
+}
+
+// match returns the first rule matching req
+func (t *cannedTransport) match(req openai.ChatCompletionRequest) (Rule, bool) {
+	for _, rule := range t.rules {
+		if rule.Match == nil || rule.Match(req) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// nextID returns a deterministic completion ID
+func (t *cannedTransport) nextID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n++
+	return fmt.Sprintf("chatcmpl-canned-%d", t.n)
+}
+
+// finishReason applies the default finish reason
+func (rule Rule) finishReason() string {
+	if rule.FinishReason == "" {
+		return "stop"
+	}
+	return rule.FinishReason
+}
+
+// chunks returns the streamed deltas of the rule
+func (rule Rule) chunks() []string {
+	if len(rule.Chunks) > 0 {
+		return rule.Chunks
+	}
+	return strings.SplitAfter(rule.Reply, " ")
+}
+
+// completion builds the non-streaming response body
+func completion(id, model string, rule Rule) map[string]any {
+	completionTokens := len(strings.Fields(rule.Reply))
+	return map[string]any{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": 0,
+		"model":   model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": rule.Reply},
+			"finish_reason": rule.finishReason(),
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     0,
+			"completion_tokens": completionTokens,
+			"total_tokens":      completionTokens,
+		},
+	}
+}
+
+// streamBody writes the rule's chunks as SSE frames, pausing ChunkDelay
+// between them and stopping when ctx is canceled
+func streamBody(ctx context.Context, id, model string, rule Rule) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		frame := func(delta map[string]string, finish any) error {
+			data, err := json.Marshal(map[string]any{
+				"id":      id,
+				"object":  "chat.completion.chunk",
+				"created": 0,
+				"model":   model,
+				"choices": []map[string]any{{
+					"index":         0,
+					"delta":         delta,
+					"finish_reason": finish,
+				}},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(pw, "data: %s\n\n", data)
+			return err
+		}
+
+		err := frame(map[string]string{"role": "assistant"}, nil)
+		for _, chunk := range rule.chunks() {
+			if err != nil {
+				break
+			}
+			if err = sleep(ctx, rule.ChunkDelay); err == nil {
+				err = frame(map[string]string{"content": chunk}, nil)
+			}
+		}
+		if err == nil {
+			err = frame(map[string]string{}, rule.finishReason())
+		}
+		if err == nil {
+			_, err = io.WriteString(pw, "data: [DONE]\n\n")
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// sleep waits d unless ctx is canceled first
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// errorResponse builds an API error envelope
+func errorResponse(r *http.Request, status int, message string) *http.Response {
+	data, _ := json.Marshal(map[string]any{
+		"error": map[string]string{"message": message, "type": "invalid_request_error"},
+	})
+	return response(r, status, "application/json", io.NopCloser(bytes.NewReader(data)))
+}
+
+// response wraps body in an HTTP response to r
+func response(r *http.Request, status int, contentType string, body io.ReadCloser) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       body,
+		Request:    r,
+	}
+}
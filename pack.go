@@ -0,0 +1,64 @@
+package openai
+
+import "github.com/jiyeol-lee/openai/token"
+
+const (
+	// messageOverheadTokens approximates the per-message framing tokens
+	messageOverheadTokens = 4
+	// replyPrimingTokens approximates the tokens that prime the assistant reply
+	replyPrimingTokens = 3
+	// lowDetailImageTokens is the fixed cost of a low detail image
+	lowDetailImageTokens = 85
+	// imageTokens approximates other images as 1024x1024 at high detail
+	imageTokens = 765
+)
+
+// MessageTokens estimates the number of prompt tokens a message consumes,
+// counting images by their detail level
+func MessageTokens(m Message) int {
+	text := m.Content
+	if len(m.Parts) > 0 {
+		text = m.Parts.Text()
+	}
+	tokens := messageOverheadTokens + token.Count(m.Role) + token.Count(m.Name) + token.Count(text)
+	for _, part := range m.Parts {
+		if part.Type != ContentPartImageURL {
+			continue
+		}
+		if part.ImageURL != nil && part.ImageURL.Detail == ImageDetailLow {
+			tokens += lowDetailImageTokens
+		} else {
+			tokens += imageTokens
+		}
+	}
+	return tokens
+}
+
+// PackMessages returns the subset of messages that fits within the model's
+// context window after reserving reserveOutputTokens for the reply. Leading
+// system and developer messages are always kept; the remaining budget is
+// filled with the most recent messages, preserving their order.
+func PackMessages(messages []Message, model string, reserveOutputTokens int) []Message {
+	budget := token.ContextWindow(model) - reserveOutputTokens - replyPrimingTokens
+
+	head := 0
+	for head < len(messages) && (messages[head].Role == RoleSystem || messages[head].Role == RoleDeveloper) {
+		budget -= MessageTokens(messages[head])
+		head++
+	}
+
+	start := len(messages)
+	for start > head {
+		cost := MessageTokens(messages[start-1])
+		if cost > budget {
+			break
+		}
+		budget -= cost
+		start--
+	}
+
+	packed := make([]Message, 0, head+len(messages)-start)
+	packed = append(packed, messages[:head]...)
+	packed = append(packed, messages[start:]...)
+	return packed
+}
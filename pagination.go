@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// SortOrder orders list results by creation time
+type SortOrder string
+
+const (
+	// SortAsc lists the oldest items first
+	SortAsc SortOrder = "asc"
+	// SortDesc lists the newest items first
+	SortDesc SortOrder = "desc"
+)
+
+// ListOptions holds the cursor pagination parameters shared by list
+// endpoints. Zero values are omitted so the API defaults apply.
+type ListOptions struct {
+	// Limit is the page size
+	Limit int
+	// After lists items following this ID
+	After string
+	// Before lists items preceding this ID
+	Before string
+	// Order sorts results by creation time
+	Order SortOrder
+}
+
+// Values encodes the options as query parameters
+func (o ListOptions) Values() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != "" {
+		q.Set("after", o.After)
+	}
+	if o.Before != "" {
+		q.Set("before", o.Before)
+	}
+	if o.Order != "" {
+		q.Set("order", string(o.Order))
+	}
+	return q
+}
+
+// Page is one page of a cursor-paginated list endpoint
+type Page[T any] struct {
+	Object  string `json:"object"`
+	Data    []T    `json:"data"`
+	FirstID string `json:"first_id,omitempty"`
+	LastID  string `json:"last_id,omitempty"`
+	HasMore bool   `json:"has_more"`
+}
+
+// Pager iterates over every item of a cursor-paginated list endpoint,
+// following the "after" cursor transparently. Like bufio.Scanner, iteration
+// stops at the first error, which is then reported by Err.
+type Pager[T any] struct {
+	client *Client
+	path   string
+	query  url.Values
+	idOf   func(T) string
+	err    error
+	// beta is the beta feature the endpoint requires, if any
+	beta string
+}
+
+// NewPager creates a pager for the list endpoint at path starting from opts.
+// idOf extracts an item's ID and is used as the next cursor when a page does
+// not report last_id; it may be nil for endpoints that always do.
+func NewPager[T any](c *Client, path string, opts ListOptions, idOf func(T) string) *Pager[T] {
+	return newPager(c, path, opts.Values(), idOf)
+}
+
+// newPager creates a pager from raw query parameters, for endpoints that add
+// filters on top of ListOptions
+func newPager[T any](c *Client, path string, query url.Values, idOf func(T) string) *Pager[T] {
+	return &Pager[T]{client: c, path: path, query: query, idOf: idOf}
+}
+
+// All returns an iterator over every item across all pages. Requests are made
+// lazily as the iteration advances, and items are decoded and yielded one at a
+// time as each page is read, so large pages are never held in memory at once.
+func (p *Pager[T]) All(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if p.beta != "" {
+			ctx = withBeta(ctx, p.beta)
+		}
+		query := url.Values{}
+		for k, v := range p.query {
+			query[k] = v
+		}
+
+		for {
+			var (
+				last  T
+				count int
+			)
+			page, err := fetchPage(ctx, p.client, p.path, query, func(item T) error {
+				if !yield(item) {
+					return errStopList
+				}
+				last = item
+				count++
+				return nil
+			})
+			if errors.Is(err, errStopList) {
+				return
+			}
+			if err != nil {
+				p.err = err
+				return
+			}
+
+			cursor := page.LastID
+			if cursor == "" && p.idOf != nil && count > 0 {
+				cursor = p.idOf(last)
+			}
+			if !page.HasMore || cursor == "" {
+				return
+			}
+			query.Set("after", cursor)
+		}
+	}
+}
+
+// Err returns the error that stopped the last iteration, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// fetchPage requests a single page of a list endpoint, passing its items to
+// yield as they are decoded. The returned page holds the cursor fields only.
+func fetchPage[T any](ctx context.Context, c *Client, path string, query url.Values, yield func(T) error) (*Page[T], error) {
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var page Page[T]
+	if err := decodeList(resp.Body, c.strict, &page, yield); err != nil {
+		if errors.Is(err, errStopList) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+	return &page, nil
+}
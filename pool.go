@@ -0,0 +1,197 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnknownPoolKey is returned for keys that were not registered with a
+// ClientPool.
+var ErrUnknownPoolKey = errors.New("unknown client pool key")
+
+// PoolMember configures the client of one pool key, typically one tenant
+type PoolMember struct {
+	// APIKey or TokenProvider authenticate the member's requests
+	APIKey        string
+	TokenProvider TokenProvider
+	// Organization and Project attribute requests to the member's account
+	Organization string
+	Project      string
+	// RequestsPerMinute limits the member's request rate, allowing bursts of
+	// up to that many requests. Zero means no limit.
+	RequestsPerMinute int
+	// Options are applied after the pool's shared options
+	Options []ClientOption
+}
+
+// PoolUsage reports the traffic of one pool key. Token counts cover calls
+// whose responses report usage; streamed completions count as requests only
+// unless they are requested with IncludeUsage.
+type PoolUsage struct {
+	Requests         int64
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// ClientPool routes traffic of many tenants through their own credentials,
+// rate limits, and usage counters. It is safe for concurrent use.
+type ClientPool struct {
+	shared []ClientOption
+
+	mu      sync.RWMutex
+	members map[string]*Client
+}
+
+// NewClientPool creates an empty pool whose clients all start from shared
+func NewClientPool(shared ...ClientOption) *ClientPool {
+	return &ClientPool{shared: shared, members: make(map[string]*Client)}
+}
+
+// Register creates the client for key, replacing any previous one along with
+// its usage counters. The idle connections of a replaced client are closed;
+// its calls in flight finish normally.
+func (p *ClientPool) Register(key string, m PoolMember) *Client {
+	opts := append([]ClientOption(nil), p.shared...)
+	if m.TokenProvider != nil {
+		opts = append(opts, WithTokenProvider(m.TokenProvider))
+	}
+	if m.Organization != "" {
+		opts = append(opts, WithOrganization(m.Organization))
+	}
+	if m.Project != "" {
+		opts = append(opts, WithProject(m.Project))
+	}
+	opts = append(opts, m.Options...)
+	opts = append(opts, func(c *Client) {
+		c.usage = &usageCounter{}
+		if m.RequestsPerMinute > 0 {
+			c.limiter = newRateLimiter(m.RequestsPerMinute)
+		}
+	})
+
+	client := NewClient(m.APIKey, opts...)
+	p.mu.Lock()
+	old := p.members[key]
+	p.members[key] = client
+	p.mu.Unlock()
+	if old != nil {
+		old.closeIdleConnections()
+	}
+	return client
+}
+
+// Remove drops the client for key and closes its idle connections; its calls
+// in flight finish normally
+func (p *ClientPool) Remove(key string) {
+	p.mu.Lock()
+	old := p.members[key]
+	delete(p.members, key)
+	p.mu.Unlock()
+	if old != nil {
+		old.closeIdleConnections()
+	}
+}
+
+// Client returns the client registered for key
+func (p *ClientPool) Client(key string) (*Client, error) {
+	p.mu.RLock()
+	client, ok := p.members[key]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPoolKey, key)
+	}
+	return client, nil
+}
+
+// Keys returns the registered keys in sorted order
+func (p *ClientPool) Keys() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	keys := make([]string, 0, len(p.members))
+	for key := range p.members {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Usage returns the traffic recorded for key since it was registered
+func (p *ClientPool) Usage(key string) (PoolUsage, error) {
+	client, err := p.Client(key)
+	if err != nil {
+		return PoolUsage{}, err
+	}
+	return client.usage.snapshot(), nil
+}
+
+// usageCounter counts requests and reported tokens of a client
+type usageCounter struct {
+	requests         atomic.Int64
+	promptTokens     atomic.Int64
+	completionTokens atomic.Int64
+}
+
+// snapshot returns the current counts
+func (u *usageCounter) snapshot() PoolUsage {
+	return PoolUsage{
+		Requests:         u.requests.Load(),
+		PromptTokens:     u.promptTokens.Load(),
+		CompletionTokens: u.completionTokens.Load(),
+	}
+}
+
+// recordStream adds the usage reported at the end of a stream
+func (u *usageCounter) recordStream(usage Usage) {
+	u.promptTokens.Add(int64(usage.PromptTokens))
+	u.completionTokens.Add(int64(usage.CompletionTokens))
+}
+
+// rateLimiter is a token bucket refilled at perMinute tokens per minute
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newRateLimiter creates a full bucket for perMinute requests per minute
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		rate:     float64(perMinute) / 60,
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// wait reserves a token, blocking until it is available or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		// Give the reservation back so a canceled call does not slow others.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
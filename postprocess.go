@@ -0,0 +1,69 @@
+package openai
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PostProcess selects cleanups applied to the text returned by the simple
+// string helpers (CreateChatCompletion and ContinueCompletion)
+type PostProcess struct {
+	// StripCodeFences removes a code fence wrapping the entire answer of a
+	// JSON mode request, which models often add even when asked for bare JSON
+	StripCodeFences bool
+	// CollapseBlankLines squeezes runs of blank lines into a single blank line.
+	// JSON mode answers are left alone.
+	CollapseBlankLines bool
+	// NormalizeQuotes replaces typographic quotes and apostrophes with ASCII
+	// ones. JSON mode answers are left alone, since quotes inside their string
+	// values would break the JSON.
+	NormalizeQuotes bool
+}
+
+// WithPostProcess enables output cleanups for the simple string helpers
+func WithPostProcess(p PostProcess) ClientOption {
+	return func(c *Client) {
+		c.postProcess = p
+	}
+}
+
+var (
+	wrappingFenceRe = regexp.MustCompile("(?s)^```[A-Za-z0-9_+-]*[ \t]*\n(.*?)\n?```$")
+	blankLinesRe    = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+	quoteReplacer   = strings.NewReplacer(
+		"“", `"`, "”", `"`, "„", `"`, "«", `"`, "»", `"`,
+		"‘", "'", "’", "'", "‚", "'", "′", "'",
+	)
+)
+
+// apply runs the cleanups enabled for the kind of answer req asked for over
+// text
+func (p PostProcess) apply(req ChatCompletionRequest, text string) string {
+	if jsonMode(req) {
+		if p.StripCodeFences {
+			if m := wrappingFenceRe.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+				text = m[1]
+			}
+		}
+		return text
+	}
+	if p.CollapseBlankLines {
+		text = blankLinesRe.ReplaceAllString(text, "\n\n")
+	}
+	if p.NormalizeQuotes {
+		text = quoteReplacer.Replace(text)
+	}
+	return text
+}
+
+// jsonMode reports whether req asks for JSON output
+func jsonMode(req ChatCompletionRequest) bool {
+	if req.ResponseFormat == nil {
+		return false
+	}
+	switch req.ResponseFormat.Type {
+	case ResponseFormatJSONObject, ResponseFormatJSONSchema:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package openai
+
+import "strings"
+
+// ModelPrice is the list price of a model in USD per million tokens
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// DefaultPricing holds list prices keyed by model name prefix. Dated snapshots
+// resolve to the longest matching prefix. Prices change over time; override
+// them through Budget.Pricing when precision matters.
+var DefaultPricing = map[string]ModelPrice{
+	"gpt-5":         {InputPerMillion: 1.25, OutputPerMillion: 10},
+	"gpt-5-mini":    {InputPerMillion: 0.25, OutputPerMillion: 2},
+	"gpt-5-nano":    {InputPerMillion: 0.05, OutputPerMillion: 0.40},
+	"gpt-4.1":       {InputPerMillion: 2, OutputPerMillion: 8},
+	"gpt-4.1-mini":  {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1-nano":  {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gpt-4o":        {InputPerMillion: 2.50, OutputPerMillion: 10},
+	"gpt-4o-mini":   {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4-turbo":   {InputPerMillion: 10, OutputPerMillion: 30},
+	"gpt-4":         {InputPerMillion: 30, OutputPerMillion: 60},
+	"gpt-3.5-turbo": {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+	"o1":            {InputPerMillion: 15, OutputPerMillion: 60},
+	"o1-mini":       {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"o3":            {InputPerMillion: 2, OutputPerMillion: 8},
+	"o3-mini":       {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"o4-mini":       {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+}
+
+// lookupPrice finds the price of model, consulting overrides before the
+// default table
+func lookupPrice(model string, overrides map[string]ModelPrice) (ModelPrice, bool) {
+	if price, ok := matchPrice(model, overrides); ok {
+		return price, true
+	}
+	return matchPrice(model, DefaultPricing)
+}
+
+func matchPrice(model string, table map[string]ModelPrice) (ModelPrice, bool) {
+	best := ""
+	var found ModelPrice
+	for prefix, price := range table {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, found = prefix, price
+		}
+	}
+	return found, best != ""
+}
+
+// Cost returns the USD cost of the given token counts at this price
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.InputPerMillion +
+		float64(completionTokens)*p.OutputPerMillion) / 1e6
+}
@@ -0,0 +1,109 @@
+// Package prompts renders chat message content from text/template templates.
+//
+// Templates are executed with missingkey=error so typos in variable names fail
+// loudly instead of silently producing empty prompts. In addition to the
+// standard template functions the following helpers are available:
+//
+//	{{json .Value}}            embeds Value as compact JSON
+//	{{code "go" .Source}}      wraps Source in a fenced code block whose fence
+//	                           cannot be closed by the content itself
+//	{{truncate 200 .Document}} cuts Document to roughly 200 tokens
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jiyeol-lee/openai"
+	"github.com/jiyeol-lee/openai/token"
+)
+
+// Template is a parsed prompt template that can be rendered repeatedly.
+type Template struct {
+	tmpl *template.Template
+}
+
+// New parses text as a prompt template.
+func New(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(funcMap).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Must is a helper that wraps a call to New and panics if the error is non-nil.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render executes the template with vars, which may be a struct or a map.
+func (t *Template) Render(vars any) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Message renders the template and wraps the result in a message with role.
+func (t *Template) Message(role string, vars any) (openai.Message, error) {
+	content, err := t.Render(vars)
+	if err != nil {
+		return openai.Message{}, err
+	}
+	return openai.Message{Role: role, Content: content}, nil
+}
+
+// Render parses and executes tmpl in one step.
+func Render(tmpl string, vars any) (string, error) {
+	t, err := New("prompt", tmpl)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(vars)
+}
+
+var funcMap = template.FuncMap{
+	"json":     jsonFunc,
+	"code":     codeFunc,
+	"truncate": truncateFunc,
+}
+
+// jsonFunc marshals v as compact JSON.
+func jsonFunc(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal template value: %w", err)
+	}
+	return string(data), nil
+}
+
+// codeFunc wraps text in a fenced code block. The fence is one backtick longer
+// than the longest backtick run in text so the content cannot terminate it.
+func codeFunc(lang, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+			continue
+		}
+		run = 0
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimSuffix(text, "\n") + "\n" + fence
+}
+
+// truncateFunc cuts text to roughly maxTokens tokens.
+func truncateFunc(maxTokens int, text string) string {
+	return token.Truncate(text, maxTokens)
+}
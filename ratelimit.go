@@ -0,0 +1,95 @@
+package openai
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitInfo reports the rate limit state sent in the x-ratelimit-*
+// response headers, so callers can pace their own traffic. Limits and
+// remaining counts are -1 when the header was absent.
+type RateLimitInfo struct {
+	LimitRequests     int
+	LimitTokens       int
+	RemainingRequests int
+	RemainingTokens   int
+	// ResetRequests and ResetTokens are the times until the limits fully
+	// replenish, or zero when absent, counted from ObservedAt
+	ResetRequests time.Duration
+	ResetTokens   time.Duration
+	// ObservedAt is when the client received the headers; it is zero for
+	// ParseRateLimitInfo results
+	ObservedAt time.Time
+}
+
+// remainingReset returns what is left at now of a reset delay observed at
+// ObservedAt
+func (r RateLimitInfo) remainingReset(reset time.Duration, now time.Time) time.Duration {
+	if r.ObservedAt.IsZero() {
+		return reset
+	}
+	return max(reset-now.Sub(r.ObservedAt), 0)
+}
+
+// ParseRateLimitInfo reads the x-ratelimit-* headers of h
+func ParseRateLimitInfo(h http.Header) RateLimitInfo {
+	return RateLimitInfo{
+		LimitRequests:     headerInt(h, "x-ratelimit-limit-requests"),
+		LimitTokens:       headerInt(h, "x-ratelimit-limit-tokens"),
+		RemainingRequests: headerInt(h, "x-ratelimit-remaining-requests"),
+		RemainingTokens:   headerInt(h, "x-ratelimit-remaining-tokens"),
+		ResetRequests:     parseResetDuration(h.Get("x-ratelimit-reset-requests")),
+		ResetTokens:       parseResetDuration(h.Get("x-ratelimit-reset-tokens")),
+	}
+}
+
+// present reports whether any rate limit header was set
+func (r RateLimitInfo) present() bool {
+	r.ObservedAt = time.Time{}
+	return r != RateLimitInfo{
+		LimitRequests:     -1,
+		LimitTokens:       -1,
+		RemainingRequests: -1,
+		RemainingTokens:   -1,
+	}
+}
+
+// headerInt parses an integer header, returning -1 when it is absent or
+// malformed
+func headerInt(h http.Header, key string) int {
+	n, err := strconv.Atoi(h.Get(key))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// RateLimit returns the rate limit state of the most recent response that
+// carried x-ratelimit-* headers, and false before any did
+func (c *Client) RateLimit() (RateLimitInfo, bool) {
+	info := c.rateLimit.Load()
+	if info == nil {
+		return RateLimitInfo{}, false
+	}
+	return *info, true
+}
+
+// observeRateLimit remembers the rate limit headers of resp
+func (c *Client) observeRateLimit(resp *http.Response) {
+	if info := observedRateLimit(resp.Header); info.present() {
+		c.rateLimit.Store(&info)
+	}
+}
+
+// observedRateLimit parses the headers of a response received now
+func observedRateLimit(h http.Header) RateLimitInfo {
+	info := ParseRateLimitInfo(h)
+	info.ObservedAt = time.Now()
+	return info
+}
+
+// RateLimit returns the rate limit state sent with the stream's response
+func (s *StreamReader) RateLimit() RateLimitInfo {
+	return s.rateLimit
+}
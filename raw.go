@@ -0,0 +1,50 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Do sends a request to an endpoint this package does not wrap yet, reusing
+// the client's authentication, retries, and error parsing. path is relative to
+// the API base URL, such as "/models". body, when non-nil, is sent as JSON,
+// and a successful response is decoded into out when out is non-nil. Errors
+// are reported as for the typed methods.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.doJSON(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if out == nil {
+		return nil
+	}
+	if err := c.decodeResponse(resp.Body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DoStream is like Do but returns the raw response for the caller to read,
+// for streaming or non-JSON endpoints. The response is not gzip-compressed, so
+// events arrive as they are sent. The caller must close the body. Non-2xx
+// responses are returned as errors with the body already consumed.
+func (c *Client) DoStream(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	return c.doJSON(withStreaming(ctx), method, path, body)
+}
+
+// doJSON sends body, when non-nil, as JSON
+func (c *Client) doJSON(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		var err error
+		reader, err = marshalRequest(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.doRequest(ctx, method, path, reader)
+}
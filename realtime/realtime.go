@@ -0,0 +1,524 @@
+// Package realtime connects to the OpenAI Realtime API over WebSocket.
+//
+// A Session reconnects automatically when the connection drops: it dials again
+// with exponential backoff, re-sends the session configuration, and replays
+// the client events of the turn that was in flight, so voice agents survive
+// brief network blips. The server starts a fresh session on every connection;
+// carry longer-lived context in Config.Session.
+//
+// A TranscriptionSession uses the same connection for transcription only,
+// turning streamed audio into interim and final transcripts.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/jiyeol-lee/openai"
+)
+
+const (
+	// defaultURL is the Realtime API endpoint
+	defaultURL = "wss://api.openai.com/v1/realtime"
+	// defaultMaxReconnects bounds consecutive reconnection attempts
+	defaultMaxReconnects = 5
+	// defaultReplayBuffer bounds the number of replayed client events
+	defaultReplayBuffer = 64
+	// defaultMinBackoff and defaultMaxBackoff bound the reconnection delay
+	defaultMinBackoff = 250 * time.Millisecond
+	defaultMaxBackoff = 8 * time.Second
+	// readLimit allows large audio deltas
+	readLimit = 16 << 20
+)
+
+// conversation is the mode of sessions opened by Dial
+var conversation = sessionMode{updateEvent: "session.update", turnDoneEvent: "response.done"}
+
+// sessionMode holds what differs between conversation and transcription
+// sessions
+type sessionMode struct {
+	// intent is sent as the intent query parameter when not empty
+	intent string
+	// updateEvent carries Config.Session
+	updateEvent string
+	// turnDoneEvent, when not empty, completes a turn and clears the replay
+	// buffer; transcription sessions trim it themselves
+	turnDoneEvent string
+	// replayBytes, when positive, bounds the replay buffer by the size of
+	// the events instead of by Config.ReplayBuffer
+	replayBytes int
+}
+
+// replayEvent is a client event kept for replay, numbered in send order
+type replayEvent struct {
+	seq  int64
+	data []byte
+}
+
+// ErrClosed is returned by Send and Recv after Close.
+var ErrClosed = errors.New("realtime session closed")
+
+// Config configures a Realtime session.
+type Config struct {
+	// APIKey authenticates the connection. TokenProvider, when set, is asked
+	// for a fresh token on every connection instead.
+	APIKey        string
+	TokenProvider openai.TokenProvider
+	// Model selects the realtime model
+	Model string
+	// URL overrides the Realtime endpoint
+	URL string
+	// Header adds headers to the handshake
+	Header http.Header
+	// HTTPClient performs the handshake; http.DefaultClient is used when nil
+	HTTPClient *http.Client
+	// Session, when set, is sent as a session.update event, or a
+	// transcription_session.update event for transcription sessions, on every
+	// connection, before replayed events
+	Session any
+	// MaxReconnects bounds consecutive reconnection attempts; defaults to 5.
+	// DisableReconnect turns reconnection off entirely.
+	MaxReconnects    int
+	DisableReconnect bool
+	// MinBackoff and MaxBackoff bound the delay between attempts. Defaults of
+	// 250ms and 8s apply when zero.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// ReplayBuffer bounds how many client events of the current turn are kept
+	// for replay; defaults to 64. The buffer is cleared when a response.done
+	// event arrives. Transcription sessions bound it by size instead, with
+	// TranscriptionConfig.ReplayBytes.
+	ReplayBuffer int
+	// OnReconnect, when set, is called after the connection was re-established
+	// with the error that interrupted the previous one
+	OnReconnect func(cause error)
+}
+
+// Event is a server event. Raw holds the full payload for decoding the
+// type-specific fields.
+type Event struct {
+	Type    string          `json:"type"`
+	EventID string          `json:"event_id,omitempty"`
+	Raw     json.RawMessage `json:"-"`
+}
+
+// Session is a Realtime connection that reconnects transparently. Send and
+// Recv may be called from different goroutines.
+type Session struct {
+	cfg  Config
+	mode sessionMode
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	gen    int
+	closed bool
+	replay []replayEvent
+	// replaySize is the size of the events in replay; nextSeq numbers the
+	// next event sent
+	replaySize int
+	nextSeq    int64
+	// done is closed by Close, interrupting a reconnection
+	done chan struct{}
+	// reconnecting is closed once the reconnection in progress, if any,
+	// ends; mu is not held meanwhile. reconnectErr is the error of the last
+	// failed one.
+	reconnecting chan struct{}
+	reconnectErr error
+}
+
+// Dial opens a Realtime session.
+func Dial(ctx context.Context, cfg Config) (*Session, error) {
+	return dial(ctx, cfg, conversation)
+}
+
+// dial opens a session of the given mode
+func dial(ctx context.Context, cfg Config, mode sessionMode) (*Session, error) {
+	if cfg.MaxReconnects <= 0 {
+		cfg.MaxReconnects = defaultMaxReconnects
+	}
+	if cfg.ReplayBuffer <= 0 {
+		cfg.ReplayBuffer = defaultReplayBuffer
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = defaultMinBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+
+	s := &Session{cfg: cfg, mode: mode, done: make(chan struct{})}
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return s, nil
+}
+
+// Send writes a client event, any value that marshals to a JSON object with a
+// type field. If the connection dropped, Send reconnects, or waits for the
+// reconnection in progress, and the event is delivered through the replay
+// buffer.
+func (s *Session) Send(ctx context.Context, event any) error {
+	_, err := s.send(ctx, event)
+	return err
+}
+
+// send is Send that also returns the number of the event in the replay
+// buffer, for forgetThrough
+func (s *Session) send(ctx context.Context, event any) (int64, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal realtime event: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, ErrClosed
+	}
+	seq := s.remember(data)
+	conn, gen := s.conn, s.gen
+	if wait := s.reconnecting; wait != nil {
+		// The event is replayed once the new connection is up.
+		s.mu.Unlock()
+		return seq, s.awaitReconnect(ctx, wait, gen)
+	}
+	err = conn.Write(ctx, websocket.MessageText, data)
+	s.mu.Unlock()
+
+	if err == nil || ctx.Err() != nil {
+		return seq, err
+	}
+	return seq, s.reconnect(ctx, gen, err)
+}
+
+// Recv reads the next server event, reconnecting when the connection drops.
+func (s *Session) Recv(ctx context.Context) (Event, error) {
+	event, _, err := s.recv(ctx)
+	return event, err
+}
+
+// recv is Recv that also returns the generation of the connection the event
+// arrived on
+func (s *Session) recv(ctx context.Context) (Event, int, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return Event{}, 0, ErrClosed
+		}
+		conn, gen := s.conn, s.gen
+		s.mu.Unlock()
+
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return Event{}, 0, ctx.Err()
+			}
+			if err := s.reconnect(ctx, gen, err); err != nil {
+				return Event{}, 0, err
+			}
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return Event{}, 0, fmt.Errorf("failed to decode realtime event: %w", err)
+		}
+		event.Raw = data
+		if s.mode.turnDoneEvent != "" && event.Type == s.mode.turnDoneEvent {
+			s.mu.Lock()
+			s.replay, s.replaySize = nil, 0
+			s.mu.Unlock()
+		}
+		return event, gen, nil
+	}
+}
+
+// Close ends the session, interrupting a reconnection in progress.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	if s.reconnecting != nil {
+		// The connection already failed; the reconnection closes its
+		// replacement.
+		return nil
+	}
+	return s.conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// remember adds an event to the replay buffer, dropping the oldest once full,
+// and returns its number. It must be called with mu held.
+func (s *Session) remember(data []byte) int64 {
+	seq := s.nextSeq
+	s.nextSeq++
+	s.replay = append(s.replay, replayEvent{seq: seq, data: data})
+	s.replaySize += len(data)
+	for len(s.replay) > 1 && s.replayFull() {
+		s.replaySize -= len(s.replay[0].data)
+		s.replay = s.replay[1:]
+	}
+	return seq
+}
+
+// replayFull reports whether the replay buffer exceeds its bound. It must be
+// called with mu held.
+func (s *Session) replayFull() bool {
+	if s.mode.replayBytes > 0 {
+		return s.replaySize > s.mode.replayBytes
+	}
+	return len(s.replay) > s.cfg.ReplayBuffer
+}
+
+// forgetThrough drops the buffered events numbered up to seq, which the
+// server has taken into a completed turn
+func (s *Session) forgetThrough(seq int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.replay) > 0 && s.replay[0].seq <= seq {
+		s.replaySize -= len(s.replay[0].data)
+		s.replay = s.replay[1:]
+	}
+}
+
+// replayState returns the generation of the current connection and the
+// number of the oldest buffered event, or nextSeq when there is none
+func (s *Session) replayState() (gen int, oldest int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.replay) == 0 {
+		return s.gen, s.nextSeq
+	}
+	return s.gen, s.replay[0].seq
+}
+
+// reconnect replaces the connection of generation gen, which failed with
+// cause. Callers racing on the same failure share one reconnection. mu is
+// released while waiting and dialing, so Send, Recv, and Close are not
+// blocked by it.
+func (s *Session) reconnect(ctx context.Context, gen int, cause error) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if s.gen != gen {
+		s.mu.Unlock()
+		return nil
+	}
+	if wait := s.reconnecting; wait != nil {
+		s.mu.Unlock()
+		return s.awaitReconnect(ctx, wait, gen)
+	}
+	if s.cfg.DisableReconnect || websocket.CloseStatus(cause) == websocket.StatusNormalClosure {
+		s.mu.Unlock()
+		return fmt.Errorf("realtime connection lost: %w", cause)
+	}
+	wait := make(chan struct{})
+	s.reconnecting = wait
+	old := s.conn
+	s.mu.Unlock()
+	_ = old.CloseNow()
+
+	err := s.redial(ctx, cause)
+
+	s.mu.Lock()
+	s.reconnecting = nil
+	s.reconnectErr = err
+	s.mu.Unlock()
+	close(wait)
+	return err
+}
+
+// redial connects again with backoff until a connection takes the replayed
+// events, ctx is done, or the session is closed. On success the connection
+// becomes the session's.
+func (s *Session) redial(ctx context.Context, cause error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var lastErr error
+	for attempt := range s.cfg.MaxReconnects {
+		if err := sleepContext(ctx, s.backoff(attempt)); err != nil {
+			return s.interrupted(err)
+		}
+
+		conn, err := s.connect(ctx)
+		if err == nil {
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				_ = conn.CloseNow()
+				return ErrClosed
+			}
+			// Replaying under mu orders the events sent meanwhile, which
+			// are buffered, before any sent on the new connection.
+			err = s.replayTo(ctx, conn)
+			if err == nil {
+				s.conn = conn
+				s.gen++
+				s.mu.Unlock()
+				if s.cfg.OnReconnect != nil {
+					s.cfg.OnReconnect(cause)
+				}
+				return nil
+			}
+			s.mu.Unlock()
+			_ = conn.CloseNow()
+		}
+		if ctx.Err() != nil {
+			return s.interrupted(ctx.Err())
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("realtime reconnect failed after %d attempts: %w", s.cfg.MaxReconnects, lastErr)
+}
+
+// interrupted returns ErrClosed when err stems from Close, and err otherwise
+func (s *Session) interrupted(err error) error {
+	select {
+	case <-s.done:
+		return ErrClosed
+	default:
+		return err
+	}
+}
+
+// awaitReconnect waits for the reconnection signalled by wait, which replaces
+// the connection of generation gen, and returns its error.
+func (s *Session) awaitReconnect(ctx context.Context, wait <-chan struct{}, gen int) error {
+	select {
+	case <-wait:
+	case <-s.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.gen != gen {
+		return nil
+	}
+	return s.reconnectErr
+}
+
+// replayTo re-sends the buffered events of the current turn on conn. It must
+// be called with mu held.
+func (s *Session) replayTo(ctx context.Context, conn *websocket.Conn) error {
+	for _, event := range s.replay {
+		if err := conn.Write(ctx, websocket.MessageText, event.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connect dials the endpoint and applies the session configuration.
+func (s *Session) connect(ctx context.Context) (*websocket.Conn, error) {
+	endpoint := s.cfg.URL
+	if endpoint == "" {
+		endpoint = defaultURL
+	}
+	if s.cfg.Model != "" || s.mode.intent != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid realtime URL: %w", err)
+		}
+		query := u.Query()
+		if s.cfg.Model != "" {
+			query.Set("model", s.cfg.Model)
+		}
+		if s.mode.intent != "" {
+			query.Set("intent", s.mode.intent)
+		}
+		u.RawQuery = query.Encode()
+		endpoint = u.String()
+	}
+
+	header := s.cfg.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	token := s.cfg.APIKey
+	if s.cfg.TokenProvider != nil {
+		var err error
+		if token, err = s.cfg.TokenProvider.Token(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get API token: %w", err)
+		}
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if header.Get("OpenAI-Beta") == "" {
+		header.Set("OpenAI-Beta", "realtime=v1")
+	}
+
+	conn, _, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{
+		HTTPClient: s.cfg.HTTPClient,
+		HTTPHeader: header,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to realtime API: %w", err)
+	}
+	conn.SetReadLimit(readLimit)
+
+	if s.cfg.Session != nil {
+		data, err := json.Marshal(struct {
+			Type    string `json:"type"`
+			Session any    `json:"session"`
+		}{s.mode.updateEvent, s.cfg.Session})
+		if err == nil {
+			err = conn.Write(ctx, websocket.MessageText, data)
+		}
+		if err != nil {
+			_ = conn.CloseNow()
+			return nil, fmt.Errorf("failed to configure realtime session: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// backoff returns the jittered delay before reconnection attempt+1
+func (s *Session) backoff(attempt int) time.Duration {
+	delay := s.cfg.MaxBackoff
+	if attempt < 30 {
+		delay = min(s.cfg.MinBackoff<<attempt, s.cfg.MaxBackoff)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,310 @@
+package realtime
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Transcription session event types.
+const (
+	// EventTranscriptDelta carries interim text of the audio being transcribed
+	EventTranscriptDelta = "conversation.item.input_audio_transcription.delta"
+	// EventTranscriptCompleted carries the final transcript of a turn
+	EventTranscriptCompleted = "conversation.item.input_audio_transcription.completed"
+	// EventTranscriptFailed reports a turn that could not be transcribed
+	EventTranscriptFailed = "conversation.item.input_audio_transcription.failed"
+	// EventError reports an invalid client event or a server failure
+	EventError = "error"
+	// EventSpeechStopped reports the end of speech found by turn detection,
+	// at AudioEndMs into the audio of the connection
+	EventSpeechStopped = "input_audio_buffer.speech_stopped"
+	// EventAudioCommitted reports that the audio of a turn was committed for
+	// transcription
+	EventAudioCommitted = "input_audio_buffer.committed"
+)
+
+// defaultTranscriptionReplayBytes bounds the buffered audio events of a
+// transcription session, about four minutes of pcm16 audio
+const defaultTranscriptionReplayBytes = 16 << 20
+
+// transcription is the mode of sessions opened by DialTranscription. Audio is
+// replayed until the server committed it to a turn; TranscriptionSession.Recv
+// trims the buffer as turns are committed.
+var transcription = sessionMode{
+	intent:      "transcription",
+	updateEvent: "transcription_session.update",
+}
+
+// TranscriptionConfig configures a transcription session. Config.Session is
+// built from the other fields and must be left nil.
+type TranscriptionConfig struct {
+	Config
+	// TranscriptionModel is such as "gpt-4o-transcribe",
+	// "gpt-4o-mini-transcribe", or "whisper-1"
+	TranscriptionModel string
+	// Language is the ISO-639-1 code of the speech, improving accuracy
+	Language string
+	// Prompt guides the transcription, such as with expected vocabulary
+	Prompt string
+	// InputAudioFormat is "pcm16", the default, "g711_ulaw", or "g711_alaw"
+	InputAudioFormat string
+	// TurnDetection replaces the default server voice activity detection,
+	// such as map[string]any{"type": "semantic_vad"}. Set
+	// DisableTurnDetection to commit turns with Commit instead.
+	TurnDetection        any
+	DisableTurnDetection bool
+	// NoiseReduction is "near_field" or "far_field"; empty disables it
+	NoiseReduction string
+	// ReplayBytes bounds the size of the buffered events not yet committed
+	// to a turn, which are replayed after a reconnect; it defaults to 16 MB,
+	// about four minutes of pcm16 audio. Beyond it the oldest audio is
+	// dropped.
+	ReplayBytes int
+}
+
+// transcriptionSession is the session object of transcription_session.update.
+type transcriptionSession struct {
+	InputAudioFormat        string                   `json:"input_audio_format,omitempty"`
+	InputAudioTranscription transcriptionModel       `json:"input_audio_transcription"`
+	TurnDetection           any                      `json:"turn_detection,omitempty"`
+	NoiseReduction          *transcriptionNoiseLevel `json:"input_audio_noise_reduction,omitempty"`
+}
+
+// transcriptionModel selects the model of a transcription session.
+type transcriptionModel struct {
+	Model    string `json:"model"`
+	Language string `json:"language,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+}
+
+// transcriptionNoiseLevel selects the noise reduction of a transcription
+// session.
+type transcriptionNoiseLevel struct {
+	Type string `json:"type"`
+}
+
+// TranscriptEvent is a server event of a transcription session. Delta is set
+// for EventTranscriptDelta and Transcript for EventTranscriptCompleted; ItemID
+// identifies the turn both belong to.
+type TranscriptEvent struct {
+	Type         string `json:"type"`
+	ItemID       string `json:"item_id,omitempty"`
+	ContentIndex int    `json:"content_index,omitempty"`
+	Delta        string `json:"delta,omitempty"`
+	Transcript   string `json:"transcript,omitempty"`
+	// AudioEndMs is set for EventSpeechStopped
+	AudioEndMs int64 `json:"audio_end_ms,omitempty"`
+	// Error is set for EventTranscriptFailed and EventError
+	Error *EventErrorDetail `json:"error,omitempty"`
+	// Raw holds the full payload
+	Raw json.RawMessage `json:"-"`
+}
+
+// Final reports whether the event holds the final transcript of a turn.
+func (e TranscriptEvent) Final() bool {
+	return e.Type == EventTranscriptCompleted
+}
+
+// EventErrorDetail describes the error of an error or failed event.
+type EventErrorDetail struct {
+	Type    string `json:"type,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
+
+// TranscriptionSession streams audio to the Realtime API for transcription
+// only, receiving interim and final transcripts as the audio arrives. It
+// reconnects like a Session; audio not yet committed to a turn is replayed,
+// up to TranscriptionConfig.ReplayBytes. Append and Commit are meant to be
+// called from one goroutine and Recv from another.
+type TranscriptionSession struct {
+	session *Session
+	// bytesPerMs converts the audio offsets of speech_stopped events
+	bytesPerMs int64
+
+	mu sync.Mutex
+	// audio lists the appended chunks still buffered for replay, oldest
+	// first, with where each ends in the audio sent on connection gen
+	audio []bufferedAudio
+	gen   int
+	sent  int64
+	// commits numbers the Commit events the server has not confirmed yet
+	commits []int64
+	// stops maps the item IDs of speech_stopped events on gen to where
+	// their audio ends
+	stops map[string]int64
+}
+
+// bufferedAudio is an appended chunk kept for replay
+type bufferedAudio struct {
+	seq  int64
+	size int64
+	end  int64
+}
+
+// DialTranscription opens a transcription session.
+func DialTranscription(ctx context.Context, cfg TranscriptionConfig) (*TranscriptionSession, error) {
+	if cfg.Session != nil {
+		return nil, errors.New("transcription config must not set Session")
+	}
+	update := transcriptionSession{
+		InputAudioFormat: cfg.InputAudioFormat,
+		InputAudioTranscription: transcriptionModel{
+			Model:    cfg.TranscriptionModel,
+			Language: cfg.Language,
+			Prompt:   cfg.Prompt,
+		},
+		TurnDetection: cfg.TurnDetection,
+	}
+	if cfg.DisableTurnDetection {
+		update.TurnDetection = json.RawMessage("null")
+	}
+	if cfg.NoiseReduction != "" {
+		update.NoiseReduction = &transcriptionNoiseLevel{Type: cfg.NoiseReduction}
+	}
+	cfg.Config.Session = update
+
+	mode := transcription
+	mode.replayBytes = cmp.Or(cfg.ReplayBytes, defaultTranscriptionReplayBytes)
+	session, err := dial(ctx, cfg.Config, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &TranscriptionSession{
+		session:    session,
+		bytesPerMs: audioBytesPerMs(cfg.InputAudioFormat),
+		stops:      make(map[string]int64),
+	}, nil
+}
+
+// audioBytesPerMs returns the data rate of an input audio format: 24 kHz
+// 16-bit mono for pcm16, 8 kHz 8-bit for G.711
+func audioBytesPerMs(format string) int64 {
+	switch format {
+	case "g711_ulaw", "g711_alaw":
+		return 8
+	default:
+		return 48
+	}
+}
+
+// Append sends a chunk of audio in the session's input format, such as the
+// latest samples from a microphone.
+func (t *TranscriptionSession) Append(ctx context.Context, audio []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seq, err := t.session.send(ctx, struct {
+		Type  string `json:"type"`
+		Audio string `json:"audio"`
+	}{"input_audio_buffer.append", base64.StdEncoding.EncodeToString(audio)})
+	if errors.Is(err, ErrClosed) {
+		return err
+	}
+	// The chunk is buffered even when sending failed, to be replayed.
+	t.audio = append(t.audio, bufferedAudio{seq: seq, size: int64(len(audio))})
+	if !t.syncLocked() {
+		t.sent += int64(len(audio))
+		t.audio[len(t.audio)-1].end = t.sent
+	}
+	return err
+}
+
+// Commit ends the current turn, for sessions without turn detection.
+func (t *TranscriptionSession) Commit(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seq, err := t.session.send(ctx, struct {
+		Type string `json:"type"`
+	}{"input_audio_buffer.commit"})
+	if errors.Is(err, ErrClosed) {
+		return err
+	}
+	t.commits = append(t.commits, seq)
+	return err
+}
+
+// Recv reads the next server event. Events other than transcripts, such as
+// input_audio_buffer.speech_started, are returned with only Type and Raw.
+// Committed turns are dropped from the replay buffer; the audio appended
+// after them is kept.
+func (t *TranscriptionSession) Recv(ctx context.Context) (TranscriptEvent, error) {
+	event, gen, err := t.session.recv(ctx)
+	if err != nil {
+		return TranscriptEvent{}, err
+	}
+	var transcript TranscriptEvent
+	if err := json.Unmarshal(event.Raw, &transcript); err != nil {
+		return TranscriptEvent{}, fmt.Errorf("failed to decode realtime event: %w", err)
+	}
+	transcript.Raw = event.Raw
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.syncLocked()
+	if gen != t.gen {
+		// The event belongs to a connection that was replaced; its
+		// offsets do not apply to the replayed audio.
+		return transcript, nil
+	}
+	switch transcript.Type {
+	case EventSpeechStopped:
+		t.stops[transcript.ItemID] = transcript.AudioEndMs * t.bytesPerMs
+	case EventAudioCommitted:
+		if end, ok := t.stops[transcript.ItemID]; ok {
+			// Turn detection committed the audio up to the end of speech.
+			delete(t.stops, transcript.ItemID)
+			last := int64(-1)
+			for _, chunk := range t.audio {
+				if chunk.end > end {
+					break
+				}
+				last = chunk.seq
+			}
+			if last >= 0 {
+				t.session.forgetThrough(last)
+			}
+		} else if len(t.commits) > 0 {
+			// Commit committed everything sent before it.
+			t.session.forgetThrough(t.commits[0])
+			t.commits = t.commits[1:]
+		}
+		t.syncLocked()
+	}
+	return transcript, nil
+}
+
+// syncLocked drops the chunks and commits no longer in the replay buffer and,
+// when the connection was replaced, recomputes where the chunks end in the
+// audio replayed on the new one. It reports whether it did the latter. It
+// must be called with mu held.
+func (t *TranscriptionSession) syncLocked() bool {
+	gen, oldest := t.session.replayState()
+	for len(t.audio) > 0 && t.audio[0].seq < oldest {
+		t.audio = t.audio[1:]
+	}
+	for len(t.commits) > 0 && t.commits[0] < oldest {
+		t.commits = t.commits[1:]
+	}
+	if gen == t.gen {
+		return false
+	}
+	t.gen = gen
+	t.sent = 0
+	for i := range t.audio {
+		t.sent += t.audio[i].size
+		t.audio[i].end = t.sent
+	}
+	clear(t.stops)
+	return true
+}
+
+// Close ends the session.
+func (t *TranscriptionSession) Close() error {
+	return t.session.Close()
+}
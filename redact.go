@@ -0,0 +1,127 @@
+package openai
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RedactionRule replaces every match of Pattern that passes Match
+type RedactionRule struct {
+	Pattern *regexp.Regexp
+	// Replacement may reference capture groups such as ${1}; it defaults to
+	// "[REDACTED]"
+	Replacement string
+	// Match, when set, confirms a candidate match, for checks a regular
+	// expression cannot express such as the Luhn checksum of card numbers
+	Match func(string) bool
+}
+
+var (
+	// EmailRule redacts email addresses
+	EmailRule = RedactionRule{
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+		Replacement: "[EMAIL]",
+	}
+	// APIKeyRule redacts OpenAI, AWS, GitHub, Slack, and Google API keys
+	APIKeyRule = RedactionRule{
+		Pattern:     regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abpr]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`),
+		Replacement: "[API_KEY]",
+	}
+	// CreditCardRule redacts card numbers of 13 to 19 digits, optionally
+	// grouped by spaces or dashes, that pass the Luhn checksum
+	CreditCardRule = RedactionRule{
+		Pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replacement: "[CREDIT_CARD]",
+		Match:       luhnValid,
+	}
+)
+
+// DefaultRedactionRules holds the built-in detectors
+var DefaultRedactionRules = []RedactionRule{APIKeyRule, EmailRule, CreditCardRule}
+
+// Redactor scrubs sensitive values from text by applying rules in order. Use
+// it with WithRequestRedactor to keep values from being sent, with
+// AuditOptions.Redact, or as the WithSSECapture redact function via Bytes.
+type Redactor struct {
+	rules []RedactionRule
+}
+
+// NewRedactor creates a redactor applying rules in order
+func NewRedactor(rules ...RedactionRule) *Redactor {
+	return &Redactor{rules: slices.Clone(rules)}
+}
+
+// Redact returns s with every confirmed match replaced
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = "[REDACTED]"
+		}
+		if rule.Match == nil {
+			s = rule.Pattern.ReplaceAllString(s, replacement)
+			continue
+		}
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if !rule.Match(match) {
+				return match
+			}
+			return rule.Pattern.ReplaceAllString(match, replacement)
+		})
+	}
+	return s
+}
+
+// Bytes is Redact for byte slices, matching the WithSSECapture signature
+func (r *Redactor) Bytes(b []byte) []byte {
+	return []byte(r.Redact(string(b)))
+}
+
+// Messages returns a copy of messages with their text redacted
+func (r *Redactor) Messages(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		msg.Content = r.Redact(msg.Content)
+		if len(msg.Parts) > 0 {
+			parts := make(MessageContent, len(msg.Parts))
+			for j, part := range msg.Parts {
+				part.Text = r.Redact(part.Text)
+				parts[j] = part
+			}
+			msg.Parts = parts
+		}
+		out[i] = msg
+	}
+	return out
+}
+
+// WithRequestRedactor scrubs the messages of chat and Responses API requests
+// with r before they are sent, so sensitive values never leave the process
+func WithRequestRedactor(r *Redactor) ClientOption {
+	return func(c *Client) {
+		c.redactor = r
+	}
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum
+func luhnValid(s string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
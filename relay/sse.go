@@ -0,0 +1,161 @@
+// Package relay forwards chat completion streams to web clients, as
+// server-sent events or over a WebSocket, so Go backends can proxy
+// completions to browsers without re-implementing the wire protocols.
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jiyeol-lee/openai"
+)
+
+// defaultHeartbeat is the interval of SSE keep-alive comments
+const defaultHeartbeat = 15 * time.Second
+
+// SSEOptions configures SSE relaying
+type SSEOptions struct {
+	// Heartbeat is the interval of keep-alive comments sent while the model
+	// is silent, so proxies do not time out idle connections. It defaults to
+	// 15s; a negative value disables heartbeats.
+	Heartbeat time.Duration
+	// Transform, when set, maps each chunk to the value sent as the event
+	// data. Returning nil skips the chunk. By default chunks are forwarded in
+	// the OpenAI wire format, followed by "[DONE]".
+	Transform func(openai.ChatCompletionStreamResponse) any
+}
+
+// received is a chunk or error read from a stream
+type received struct {
+	chunk openai.ChatCompletionStreamResponse
+	err   error
+}
+
+// pump reads stream in the background until it ends. The channel is closed
+// after the final value, which always carries an error (io.EOF on success).
+func pump(stream *openai.StreamReader) <-chan received {
+	ch := make(chan received, 1)
+	go func() {
+		defer close(ch)
+		for {
+			chunk, err := stream.Recv()
+			ch <- received{chunk: chunk, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// release closes stream, which unblocks the reader, and waits for the reader
+// to finish so it does not outlive the relay
+func release(stream *openai.StreamReader, chunks <-chan received) {
+	stream.Close()
+	for range chunks {
+	}
+}
+
+// SSE relays stream to w as server-sent events and closes the stream. It
+// returns when the stream ends, fails, or ctx is done; pass the request's
+// context so a disconnecting browser stops the upstream request. Upstream
+// errors are sent as an "error" event before being returned.
+func SSE(ctx context.Context, w http.ResponseWriter, stream *openai.StreamReader, opts SSEOptions) error {
+	rc := http.NewResponseController(w)
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		stream.Close()
+		return fmt.Errorf("response writer cannot stream: %w", err)
+	}
+
+	interval := opts.Heartbeat
+	if interval == 0 {
+		interval = defaultHeartbeat
+	}
+	var heartbeat <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	chunks := pump(stream)
+	defer release(stream, chunks)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-heartbeat:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+
+		case r := <-chunks:
+			if errors.Is(r.err, io.EOF) {
+				if opts.Transform == nil {
+					if _, err := io.WriteString(w, "data: [DONE]\n\n"); err != nil {
+						return err
+					}
+				}
+				return rc.Flush()
+			}
+			if r.err != nil {
+				writeSSEError(w, r.err)
+				_ = rc.Flush()
+				return r.err
+			}
+
+			if err := writeSSEChunk(w, r.chunk, opts.Transform); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeSSEChunk writes one chunk as a data event
+func writeSSEChunk(w io.Writer, chunk openai.ChatCompletionStreamResponse, transform func(openai.ChatCompletionStreamResponse) any) error {
+	data := []byte(chunk.Raw)
+	if transform != nil {
+		value := transform(chunk)
+		if value == nil {
+			return nil
+		}
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("failed to marshal relayed event: %w", err)
+		}
+	} else if len(data) == 0 {
+		var err error
+		if data, err = json.Marshal(chunk); err != nil {
+			return fmt.Errorf("failed to marshal relayed event: %w", err)
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// writeSSEError reports an upstream error to the browser
+func writeSSEError(w io.Writer, err error) {
+	data, _ := json.Marshal(map[string]any{
+		"error": map[string]string{"message": err.Error()},
+	})
+	_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+}
@@ -0,0 +1,228 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/jiyeol-lee/openai"
+)
+
+// defaultWriteTimeout bounds how long a WebSocket client may stall a write
+const defaultWriteTimeout = 10 * time.Second
+
+// Event types sent over a WebSocket relay
+const (
+	// EventDelta carries new content of one choice
+	EventDelta = "delta"
+	// EventDone ends a successful stream
+	EventDone = "done"
+	// EventError reports an upstream failure before the connection closes
+	EventError = "error"
+)
+
+// Event is a JSON message sent to WebSocket clients
+type Event struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	Role         string `json:"role,omitempty"`
+	Content      string `json:"content,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// WebSocketOptions configures WebSocket relaying
+type WebSocketOptions struct {
+	// WriteTimeout bounds each write. A client that stalls longer is closed
+	// with StatusPolicyViolation. It defaults to 10s.
+	WriteTimeout time.Duration
+}
+
+// cancelMessage is the client message that stops a relay
+type cancelMessage struct {
+	Type string `json:"type"`
+}
+
+// errSlowClient closes connections whose client stopped reading
+var errSlowClient = errors.New("websocket client too slow")
+
+// WebSocket relays stream deltas to conn as JSON Events, then closes both.
+//
+// While the client lags behind, pending deltas of a choice are merged into
+// one event rather than queued, and the upstream read waits for the client;
+// a client stalling past WriteTimeout is disconnected. A finished stream is
+// followed by a "done" event and a normal closure, an upstream failure by an
+// "error" event and StatusInternalError. The client stops the relay by
+// sending {"type":"cancel"} or closing the connection.
+func WebSocket(ctx context.Context, conn *websocket.Conn, stream *openai.StreamReader, opts WebSocketOptions) error {
+	timeout := opts.WriteTimeout
+	if timeout <= 0 {
+		timeout = defaultWriteTimeout
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go readClient(ctx, conn, cancel)
+
+	chunks := pump(stream)
+	defer release(stream, chunks)
+
+	write := func(events ...Event) error {
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal relayed event: %w", err)
+			}
+			wctx, done := context.WithTimeout(ctx, timeout)
+			err = conn.Write(wctx, websocket.MessageText, data)
+			done()
+			if err != nil {
+				if ctx.Err() != nil {
+					return context.Cause(ctx)
+				}
+				if errors.Is(wctx.Err(), context.DeadlineExceeded) {
+					return errSlowClient
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	// next holds the end of the stream when coalesce ran into it
+	var next *received
+	for {
+		var r received
+		if next != nil {
+			r, next = *next, nil
+		} else {
+			select {
+			case <-ctx.Done():
+				return closeWebSocket(conn, context.Cause(ctx))
+			case r = <-chunks:
+			}
+		}
+
+		switch {
+		case errors.Is(r.err, io.EOF):
+			if err := write(Event{Type: EventDone}); err != nil {
+				return closeWebSocket(conn, err)
+			}
+			return conn.Close(websocket.StatusNormalClosure, "")
+		case r.err != nil:
+			_ = write(Event{Type: EventError, Message: r.err.Error()})
+			_ = conn.Close(websocket.StatusInternalError, "upstream error")
+			return r.err
+		}
+
+		events, last := coalesce(deltaEvents(r.chunk), chunks)
+		if err := write(events...); err != nil {
+			return closeWebSocket(conn, err)
+		}
+		if last.err != nil {
+			next = &last
+		}
+	}
+}
+
+// coalesce merges chunks that are already waiting into events, so a lagging
+// client receives one event per choice instead of a backlog. It stops at the
+// first error, which it returns for the caller to handle.
+func coalesce(events []Event, chunks <-chan received) ([]Event, received) {
+	for {
+		select {
+		case r, ok := <-chunks:
+			if !ok {
+				return events, received{err: io.EOF}
+			}
+			if r.err != nil {
+				return events, r
+			}
+			events = mergeEvents(events, deltaEvents(r.chunk))
+		default:
+			return events, received{}
+		}
+	}
+}
+
+// mergeEvents appends the content of next to the matching pending events.
+// Events after a finish reason are kept separate.
+func mergeEvents(pending, next []Event) []Event {
+	for _, event := range next {
+		merged := false
+		for i := range pending {
+			p := &pending[i]
+			if p.Index == event.Index && p.FinishReason == "" {
+				p.Content += event.Content
+				if p.Role == "" {
+					p.Role = event.Role
+				}
+				p.FinishReason = event.FinishReason
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			pending = append(pending, event)
+		}
+	}
+	return pending
+}
+
+// deltaEvents converts a chunk into one event per choice
+func deltaEvents(chunk openai.ChatCompletionStreamResponse) []Event {
+	events := make([]Event, 0, len(chunk.Choices))
+	for _, choice := range chunk.Choices {
+		event := Event{
+			Type:    EventDelta,
+			Index:   choice.Index,
+			Role:    choice.Delta.Role,
+			Content: choice.Delta.Content,
+		}
+		if choice.FinishReason != nil {
+			event.FinishReason = *choice.FinishReason
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// readClient watches client messages and cancels the relay when the client
+// asks for it or goes away
+func readClient(ctx context.Context, conn *websocket.Conn, cancel context.CancelCauseFunc) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			cancel(err)
+			return
+		}
+		var msg cancelMessage
+		if json.Unmarshal(data, &msg) == nil && msg.Type == "cancel" {
+			cancel(context.Canceled)
+			return
+		}
+	}
+}
+
+// closeWebSocket ends the connection with a status matching cause and
+// returns the error the relay reports
+func closeWebSocket(conn *websocket.Conn, cause error) error {
+	switch {
+	case errors.Is(cause, context.Canceled):
+		_ = conn.Close(websocket.StatusNormalClosure, "canceled")
+		return cause
+	case errors.Is(cause, errSlowClient):
+		_ = conn.Close(websocket.StatusPolicyViolation, "client too slow")
+		return cause
+	case websocket.CloseStatus(cause) != -1:
+		// The client closed the connection.
+		return nil
+	default:
+		_ = conn.CloseNow()
+		return cause
+	}
+}
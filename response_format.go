@@ -0,0 +1,59 @@
+package openai
+
+// Response format types
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat constrains the output of the model. JSON mode
+// ("json_object") guarantees valid JSON; structured outputs ("json_schema")
+// guarantee JSON matching a schema. JSON mode also requires the word "JSON"
+// to appear in the messages.
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat is the schema of a "json_schema" response format
+type JSONSchemaFormat struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Schema is a *JSONSchema, a json.RawMessage, or any value marshaling to
+	// a JSON schema
+	Schema any `json:"schema"`
+	// Strict makes the output follow Schema exactly. Strict schemas must list
+	// every property in Required and set AdditionalProperties to false.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// JSONObjectFormat returns the response format of JSON mode
+func JSONObjectFormat() *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// JSONSchemaResponseFormat returns a structured outputs response format
+func JSONSchemaResponseFormat(name string, schema any, strict bool) *ResponseFormat {
+	return &ResponseFormat{
+		Type:       ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchemaFormat{Name: name, Schema: schema, Strict: strict},
+	}
+}
+
+// JSONSchema is the subset of JSON Schema supported by structured outputs
+type JSONSchema struct {
+	// Type is "object", "array", "string", "number", "integer", "boolean",
+	// or "null"
+	Type        string                 `json:"type,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	Enum        []any                  `json:"enum,omitempty"`
+	AnyOf       []*JSONSchema          `json:"anyOf,omitempty"`
+	// AdditionalProperties must point to false for strict schemas
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
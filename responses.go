@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// PromptReference points a Responses request at a prompt object managed in
+// the OpenAI dashboard
+type PromptReference struct {
+	// ID is the prompt object ID, such as "pmpt_123"
+	ID string `json:"id"`
+	// Version pins a prompt version; the current version is used when empty
+	Version string `json:"version,omitempty"`
+	// Variables fill the prompt's template variables. Values are strings or
+	// input content parts.
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// ResponseRequest represents a Responses API request
+type ResponseRequest struct {
+	Model string `json:"model,omitempty"`
+	// Input holds the conversation; it may be empty when Prompt supplies it
+	Input        []Message         `json:"input,omitempty"`
+	Instructions string            `json:"instructions,omitempty"`
+	Prompt       *PromptReference  `json:"prompt,omitempty"`
+	Temperature  float32           `json:"temperature,omitempty"`
+	Store        *bool             `json:"store,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r ResponseRequest) MarshalJSON() ([]byte, error) {
+	type alias ResponseRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// ResponseContent is a content part of a response output item
+type ResponseContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// ResponseOutputItem is an item produced by the model, such as a message
+type ResponseOutputItem struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	Status  string            `json:"status,omitempty"`
+	Role    string            `json:"role,omitempty"`
+	Content []ResponseContent `json:"content,omitempty"`
+}
+
+// Response represents a Responses API response
+type Response struct {
+	ID        string               `json:"id"`
+	Object    string               `json:"object"`
+	CreatedAt int64                `json:"created_at"`
+	Model     string               `json:"model"`
+	Status    string               `json:"status"`
+	Output    []ResponseOutputItem `json:"output"`
+	Usage     struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// OutputText concatenates the text of every output message
+func (r *Response) OutputText() string {
+	var b strings.Builder
+	for _, item := range r.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				b.WriteString(part.Text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// CreateResponse sends a Responses API request. Set Prompt to use a prompt
+// object from the dashboard with its variables filled in.
+func (c *Client) CreateResponse(ctx context.Context, req ResponseRequest) (*Response, error) {
+	if c.redactor != nil {
+		req.Input = c.redactor.Messages(req.Input)
+		req.Instructions = c.redactor.Redact(req.Instructions)
+	}
+
+	var resp Response
+	if err := c.Do(ctx, http.MethodPost, "/responses", req, &resp); err != nil {
+		return nil, err
+	}
+
+	c.recordUsage(resp.Model, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+
+	return &resp, nil
+}
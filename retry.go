@@ -0,0 +1,163 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultMinBackoff is the delay before the first retry
+	defaultMinBackoff = 500 * time.Millisecond
+	// defaultMaxBackoff caps the delay between retries
+	defaultMaxBackoff = 8 * time.Second
+	// defaultMaxRetryAfter caps the Retry-After delay that is waited out
+	defaultMaxRetryAfter = time.Minute
+)
+
+// RetryTransport is an http.RoundTripper that retries failed requests with
+// jittered exponential backoff. It retries connection errors and 408, 409,
+// 429, and 5xx responses, so it can be reused for other services and combined
+// with custom transports. A Retry-After or retry-after-ms header on a retried
+// response replaces the backoff delay.
+type RetryTransport struct {
+	// Base sends the requests. http.DefaultTransport is used when nil.
+	Base http.RoundTripper
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the delay between attempts. Defaults of
+	// 500ms and 8s apply when zero.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After delay waited out; a response
+	// asking for more is returned without retrying. It defaults to 1m.
+	MaxRetryAfter time.Duration
+	// ShouldRetry overrides which outcomes are retried. resp is nil when err
+	// is set.
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+// RoundTrip sends req, retrying according to the transport's policy. Request
+// bodies are replayed through req.GetBody; requests whose body cannot be
+// replayed are sent once.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	shouldRetry := t.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = defaultShouldRetry
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := base.RoundTrip(req)
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if attempt >= t.MaxRetries || !replayable || req.Context().Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if wait := parseRetryAfter(resp.Header); wait > 0 {
+				if wait > t.maxRetryAfter() {
+					return resp, nil
+				}
+				delay = wait
+			}
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+			resp.Body.Close()
+		}
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the base transport
+func (t *RetryTransport) CloseIdleConnections() {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if closer, ok := base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt+1
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	minDelay, maxDelay := t.MinBackoff, t.MaxBackoff
+	if minDelay <= 0 {
+		minDelay = defaultMinBackoff
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxBackoff
+	}
+	delay := maxDelay
+	if attempt < 30 {
+		delay = min(minDelay<<attempt, maxDelay)
+	}
+	// Full jitter over the upper half spreads out clients retrying together.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// maxRetryAfter applies the default of MaxRetryAfter
+func (t *RetryTransport) maxRetryAfter() time.Duration {
+	if t.MaxRetryAfter <= 0 {
+		return defaultMaxRetryAfter
+	}
+	return t.MaxRetryAfter
+}
+
+// defaultShouldRetry retries connection failures and transient statuses,
+// deferring to the server's x-should-retry hint when present
+func defaultShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.Header.Get("X-Should-Retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return resp.StatusCode >= 500
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WithMaxRetries retries failed requests up to maxRetries times using
+// RetryTransport around the client's HTTP transport. It applies regardless of
+// whether WithHTTPClient comes before or after it.
+func WithMaxRetries(maxRetries int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
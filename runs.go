@@ -0,0 +1,419 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Run statuses
+const (
+	RunStatusQueued         = "queued"
+	RunStatusInProgress     = "in_progress"
+	RunStatusRequiresAction = "requires_action"
+	RunStatusCancelling     = "cancelling"
+	RunStatusCancelled      = "cancelled"
+	RunStatusFailed         = "failed"
+	RunStatusCompleted      = "completed"
+	RunStatusIncomplete     = "incomplete"
+	RunStatusExpired        = "expired"
+)
+
+// Run stream event names. Events of steps and messages are named after the
+// object and its status in the same way, such as "thread.run.step.completed".
+const (
+	EventThreadCreated           = "thread.created"
+	EventThreadRunCreated        = "thread.run.created"
+	EventThreadRunRequiresAction = "thread.run.requires_action"
+	EventThreadRunCompleted      = "thread.run.completed"
+	EventThreadRunFailed         = "thread.run.failed"
+	EventThreadRunStepDelta      = "thread.run.step.delta"
+	EventThreadMessageCreated    = "thread.message.created"
+	EventThreadMessageDelta      = "thread.message.delta"
+	EventThreadMessageCompleted  = "thread.message.completed"
+)
+
+// RunRequest starts a run of an assistant on a thread. Fields other than
+// AssistantID override the assistant's configuration for this run.
+type RunRequest struct {
+	AssistantID            string                 `json:"assistant_id"`
+	Model                  string                 `json:"model,omitempty"`
+	Instructions           string                 `json:"instructions,omitempty"`
+	AdditionalInstructions string                 `json:"additional_instructions,omitempty"`
+	AdditionalMessages     []ThreadMessageRequest `json:"additional_messages,omitempty"`
+	Tools                  []Tool                 `json:"tools,omitempty"`
+	Metadata               map[string]string      `json:"metadata,omitempty"`
+	Temperature            *float32               `json:"temperature,omitempty"`
+	TopP                   *float32               `json:"top_p,omitempty"`
+	MaxPromptTokens        int                    `json:"max_prompt_tokens,omitempty"`
+	MaxCompletionTokens    int                    `json:"max_completion_tokens,omitempty"`
+	ToolChoice             any                    `json:"tool_choice,omitempty"`
+	ParallelToolCalls      *bool                  `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat         *ResponseFormat        `json:"response_format,omitempty"`
+	// Stream is set automatically by the methods (don't set manually)
+	Stream bool `json:"stream,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r RunRequest) MarshalJSON() ([]byte, error) {
+	type alias RunRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// Run is an execution of an assistant on a thread
+type Run struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"`
+	CreatedAt   int64  `json:"created_at"`
+	ThreadID    string `json:"thread_id"`
+	AssistantID string `json:"assistant_id"`
+	// Status is one of the RunStatus constants
+	Status string `json:"status"`
+	// RequiredAction lists the tool calls to answer with SubmitToolOutputs
+	// while Status is RunStatusRequiresAction
+	RequiredAction    *RequiredAction    `json:"required_action,omitempty"`
+	LastError         *RunError          `json:"last_error,omitempty"`
+	IncompleteDetails *IncompleteDetails `json:"incomplete_details,omitempty"`
+	Model             string             `json:"model"`
+	Instructions      string             `json:"instructions,omitempty"`
+	Tools             []Tool             `json:"tools,omitempty"`
+	Usage             *Usage             `json:"usage,omitempty"`
+	ExpiresAt         int64              `json:"expires_at,omitempty"`
+	StartedAt         int64              `json:"started_at,omitempty"`
+	CompletedAt       int64              `json:"completed_at,omitempty"`
+	CancelledAt       int64              `json:"cancelled_at,omitempty"`
+	FailedAt          int64              `json:"failed_at,omitempty"`
+	Metadata          map[string]string  `json:"metadata,omitempty"`
+}
+
+// Terminal reports whether the run has ended
+func (r *Run) Terminal() bool {
+	switch r.Status {
+	case RunStatusCancelled, RunStatusFailed, RunStatusCompleted, RunStatusIncomplete, RunStatusExpired:
+		return true
+	}
+	return false
+}
+
+// RequiredAction is what a run waits for
+type RequiredAction struct {
+	Type              string `json:"type"`
+	SubmitToolOutputs struct {
+		ToolCalls []ToolCall `json:"tool_calls"`
+	} `json:"submit_tool_outputs"`
+}
+
+// RunError is the reason a run or step failed
+type RunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// IncompleteDetails explains why a run ended early, such as
+// "max_completion_tokens"
+type IncompleteDetails struct {
+	Reason string `json:"reason"`
+}
+
+// ToolOutput is the result of a tool call requested by a run
+type ToolOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+// RunStep is a step of a run: creating a message or calling tools
+type RunStep struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"`
+	CreatedAt   int64  `json:"created_at"`
+	RunID       string `json:"run_id"`
+	AssistantID string `json:"assistant_id"`
+	ThreadID    string `json:"thread_id"`
+	// Type is "message_creation" or "tool_calls"
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	LastError *RunError `json:"last_error,omitempty"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	// StepDetails names the created message or lists the tool calls,
+	// undecoded
+	StepDetails json.RawMessage `json:"step_details"`
+}
+
+// RunStepDelta is a fragment of a step, such as the code a code interpreter
+// call is writing
+type RunStepDelta struct {
+	ID     string `json:"id"`
+	Object string `json:"object"`
+	// Delta holds the changed step details, undecoded
+	Delta json.RawMessage `json:"delta"`
+}
+
+// MessageDelta is a fragment of a message being written by a run
+type MessageDelta struct {
+	ID     string `json:"id"`
+	Object string `json:"object"`
+	Delta  struct {
+		Role    string                `json:"role,omitempty"`
+		Content []MessageDeltaContent `json:"content,omitempty"`
+	} `json:"delta"`
+}
+
+// Text concatenates the text fragments of the delta
+func (d *MessageDelta) Text() string {
+	var b strings.Builder
+	for _, part := range d.Delta.Content {
+		if part.Text != nil {
+			b.WriteString(part.Text.Value)
+		}
+	}
+	return b.String()
+}
+
+// MessageDeltaContent is a fragment of the message part at Index
+type MessageDeltaContent struct {
+	Index int `json:"index"`
+	ThreadMessageContent
+}
+
+// RunStreamEvent is an event of a run stream. Event names the event, and the
+// field matching its object is set: Thread for "thread.created", Run for
+// "thread.run.*", Step and StepDelta for "thread.run.step.*", and Message and
+// MessageDelta for "thread.message.*". Other events only carry Data.
+type RunStreamEvent struct {
+	Event        string
+	Thread       *Thread
+	Run          *Run
+	Step         *RunStep
+	StepDelta    *RunStepDelta
+	Message      *ThreadMessage
+	MessageDelta *MessageDelta
+	// Data is the undecoded data of the event
+	Data json.RawMessage
+}
+
+// RunStream reads the events of a streaming run
+type RunStream struct {
+	events *eventReader
+	strict bool
+}
+
+// Recv returns the next event, or io.EOF after the "done" event. An error
+// event is returned as an *APIError.
+func (s *RunStream) Recv() (RunStreamEvent, error) {
+	ev, err := s.events.next()
+	if err != nil {
+		return RunStreamEvent{}, err
+	}
+	if ev.name == "error" {
+		return RunStreamEvent{}, streamEventError(ev.data)
+	}
+
+	event := RunStreamEvent{Event: ev.name, Data: ev.data}
+	var target any
+	switch {
+	case ev.name == EventThreadCreated:
+		event.Thread = new(Thread)
+		target = event.Thread
+	case ev.name == EventThreadRunStepDelta:
+		event.StepDelta = new(RunStepDelta)
+		target = event.StepDelta
+	case strings.HasPrefix(ev.name, "thread.run.step."):
+		event.Step = new(RunStep)
+		target = event.Step
+	case strings.HasPrefix(ev.name, "thread.run."):
+		event.Run = new(Run)
+		target = event.Run
+	case ev.name == EventThreadMessageDelta:
+		event.MessageDelta = new(MessageDelta)
+		target = event.MessageDelta
+	case strings.HasPrefix(ev.name, "thread.message."):
+		event.Message = new(ThreadMessage)
+		target = event.Message
+	default:
+		return event, nil
+	}
+	if err := decodeJSON(ev.data, target, s.strict); err != nil {
+		return RunStreamEvent{}, fmt.Errorf("failed to decode %s event: %w", ev.name, err)
+	}
+	return event, nil
+}
+
+// Close closes the stream
+func (s *RunStream) Close() error {
+	return s.events.close()
+}
+
+// CreateRun starts a run of an assistant on a thread
+func (c *Client) CreateRun(ctx context.Context, threadID string, req RunRequest) (*Run, error) {
+	req.Stream = false
+	var run Run
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, threadPath(threadID, "runs"), req, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// CreateRunStream starts a run and streams its events. The caller must close
+// the stream.
+func (c *Client) CreateRunStream(ctx context.Context, threadID string, req RunRequest) (*RunStream, error) {
+	req.Stream = true
+	return c.runStream(ctx, threadPath(threadID, "runs"), req)
+}
+
+// GetRun retrieves a run
+func (c *Client) GetRun(ctx context.Context, threadID, runID string) (*Run, error) {
+	var run Run
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodGet, threadPath(threadID, "runs", runID), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// CancelRun cancels a run in progress
+func (c *Client) CancelRun(ctx context.Context, threadID, runID string) (*Run, error) {
+	var run Run
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, threadPath(threadID, "runs", runID, "cancel"), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns lists the runs of a thread
+func (c *Client) ListRuns(threadID string, opts ListOptions) *Pager[Run] {
+	p := NewPager(c, threadPath(threadID, "runs"), opts, func(r Run) string { return r.ID })
+	p.beta = assistantsBeta
+	return p
+}
+
+// ListRunSteps lists the steps of a run
+func (c *Client) ListRunSteps(threadID, runID string, opts ListOptions) *Pager[RunStep] {
+	p := NewPager(c, threadPath(threadID, "runs", runID, "steps"), opts, func(s RunStep) string { return s.ID })
+	p.beta = assistantsBeta
+	return p
+}
+
+// toolOutputsRequest is the body of a submit_tool_outputs request
+type toolOutputsRequest struct {
+	ToolOutputs []ToolOutput `json:"tool_outputs"`
+	Stream      bool         `json:"stream,omitempty"`
+}
+
+// SubmitToolOutputs answers the tool calls of a run waiting in
+// RunStatusRequiresAction, which then continues
+func (c *Client) SubmitToolOutputs(ctx context.Context, threadID, runID string, outputs []ToolOutput) (*Run, error) {
+	var run Run
+	path := threadPath(threadID, "runs", runID, "submit_tool_outputs")
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, path, toolOutputsRequest{ToolOutputs: outputs}, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// SubmitToolOutputsStream is like SubmitToolOutputs but streams the events of
+// the continued run. The caller must close the stream.
+func (c *Client) SubmitToolOutputsStream(ctx context.Context, threadID, runID string, outputs []ToolOutput) (*RunStream, error) {
+	path := threadPath(threadID, "runs", runID, "submit_tool_outputs")
+	return c.runStream(ctx, path, toolOutputsRequest{ToolOutputs: outputs, Stream: true})
+}
+
+// runStream posts body to path and returns the stream of run events
+func (c *Client) runStream(ctx context.Context, path string, body any) (*RunStream, error) {
+	resp, err := c.doJSON(withStreaming(withBeta(ctx, assistantsBeta)), http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return &RunStream{events: newEventReader(resp.Body), strict: c.strict}, nil
+}
+
+// RunThread streams a run of an assistant on a thread, answering its tool
+// calls with the registered handlers, and returns the run once it ended; its
+// Status tells whether it completed. onEvent, when non-nil, receives every
+// event, such as the message deltas to display; an error from it stops the
+// run stream and is returned. The handlers' tools are not added to the run,
+// since assistants declare their own. MaxIterations bounds the tool output
+// submissions and Timeout the whole run.
+func (r *ToolRunner) RunThread(
+	ctx context.Context,
+	threadID string,
+	req RunRequest,
+	onEvent func(RunStreamEvent) error,
+) (*Run, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	maxIterations := r.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultMaxToolIterations
+	}
+	sequential := req.ParallelToolCalls != nil && !*req.ParallelToolCalls
+
+	stream, err := r.client.CreateRunStream(ctx, threadID, req)
+	if err != nil {
+		return nil, err
+	}
+	for iteration := 0; ; iteration++ {
+		run, err := r.readRun(stream, onEvent)
+		stream.Close()
+		if err != nil {
+			return run, err
+		}
+		if run.Status != RunStatusRequiresAction || run.RequiredAction == nil {
+			return run, nil
+		}
+		if iteration >= maxIterations {
+			return run, fmt.Errorf("%w: %d", ErrToolIterations, maxIterations)
+		}
+
+		results := r.execute(ctx, run.RequiredAction.SubmitToolOutputs.ToolCalls, sequential)
+		outputs := make([]ToolOutput, len(results))
+		for i, result := range results {
+			outputs[i] = ToolOutput{ToolCallID: result.ToolCallID, Output: result.Content}
+		}
+		stream, err = r.client.SubmitToolOutputsStream(ctx, threadID, run.ID, outputs)
+		if err != nil {
+			return run, err
+		}
+	}
+}
+
+// readRun reads stream until the run ends or waits for tool outputs and
+// returns its last state
+func (r *ToolRunner) readRun(stream *RunStream, onEvent func(RunStreamEvent) error) (*Run, error) {
+	var run *Run
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			if run == nil {
+				return nil, fmt.Errorf("run stream ended without a run: %w", io.ErrUnexpectedEOF)
+			}
+			return run, nil
+		}
+		if err != nil {
+			return run, err
+		}
+		if onEvent != nil {
+			if err := onEvent(event); err != nil {
+				return run, err
+			}
+		}
+		if event.Run == nil {
+			continue
+		}
+		run = event.Run
+		if run.Terminal() || run.Status == RunStatusRequiresAction {
+			return run, nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// SchemaOf derives a strict structured outputs schema from T. Struct fields
+// are named by their json tags, skipping "-", and described by a
+// `description` tag; all of them are required, and pointer fields accept
+// null. Maps, interfaces, channels, functions, and recursive types are not
+// supported.
+func SchemaOf[T any]() (*JSONSchema, error) {
+	return schemaFor(reflect.TypeFor[T](), nil)
+}
+
+// schemaFor derives the schema of t; seen holds the structs being derived, to
+// reject recursion
+func schemaFor(t reflect.Type, seen []reflect.Type) (*JSONSchema, error) {
+	switch t {
+	case timeType:
+		return &JSONSchema{Type: "string", Description: "RFC 3339 date-time"}, nil
+	case rawMessageType:
+		return nil, fmt.Errorf("unsupported schema type %s", t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}, nil
+	case reflect.Pointer:
+		elem, err := schemaFor(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{AnyOf: []*JSONSchema{elem, {Type: "null"}}}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Description: "base64"}, nil
+		}
+		items, err := schemaFor(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: "array", Items: items}, nil
+	case reflect.Struct:
+		return structSchema(t, seen)
+	}
+	return nil, fmt.Errorf("unsupported schema type %s", t)
+}
+
+// structSchema derives the object schema of a struct, flattening embedded
+// structs without a json name as encoding/json does
+func structSchema(t reflect.Type, seen []reflect.Type) (*JSONSchema, error) {
+	for _, s := range seen {
+		if s == t {
+			return nil, fmt.Errorf("unsupported recursive schema type %s", t)
+		}
+	}
+	seen = append(seen, t)
+
+	no := false
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		Required:             []string{},
+		AdditionalProperties: &no,
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := structSchema(field.Type, seen)
+			if err != nil {
+				return nil, err
+			}
+			for _, prop := range embedded.Required {
+				// Fields of the outer struct take precedence.
+				if _, ok := schema.Properties[prop]; !ok {
+					schema.Required = append(schema.Required, prop)
+					schema.Properties[prop] = embedded.Properties[prop]
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := schemaFor(field.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if desc := field.Tag.Get("description"); desc != "" {
+			prop.Description = desc
+		}
+		if _, ok := schema.Properties[name]; !ok {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	return schema, nil
+}
@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Speech audio formats. SpeechFormatPCM is raw 16-bit little-endian mono
+// samples at 24 kHz, the quickest to start playing.
+const (
+	SpeechFormatMP3  = "mp3"
+	SpeechFormatOpus = "opus"
+	SpeechFormatAAC  = "aac"
+	SpeechFormatFLAC = "flac"
+	SpeechFormatWAV  = "wav"
+	SpeechFormatPCM  = "pcm"
+)
+
+// SpeechRequest represents a text-to-speech request
+type SpeechRequest struct {
+	// Model is such as "gpt-4o-mini-tts", "tts-1", or "tts-1-hd"
+	Model string `json:"model"`
+	// Input is the text to speak, up to 4096 characters
+	Input string `json:"input"`
+	// Voice is such as "alloy", "coral", "nova", or "shimmer"
+	Voice string `json:"voice"`
+	// Instructions steer the tone and delivery; not supported by tts-1
+	Instructions string `json:"instructions,omitempty"`
+	// ResponseFormat is one of the SpeechFormat constants; the API defaults
+	// to mp3
+	ResponseFormat string `json:"response_format,omitempty"`
+	// Speed, between 0.25 and 4, defaults to 1
+	Speed float32 `json:"speed,omitempty"`
+	// ExtraFields are merged into the request body, overriding typed fields
+	// of the same name, for parameters this package does not model yet
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the request, merging ExtraFields into the body
+func (r SpeechRequest) MarshalJSON() ([]byte, error) {
+	type alias SpeechRequest
+	data, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	return withExtraFields(data, r.ExtraFields)
+}
+
+// CreateSpeech converts text to speech and returns the audio as it is
+// generated, so it can be piped to a player or file without buffering it
+// whole. The stream client and idle timeout of streaming calls apply. The
+// caller must close the audio.
+func (c *Client) CreateSpeech(ctx context.Context, req SpeechRequest) (io.ReadCloser, error) {
+	resp, err := c.doJSON(withStreaming(ctx), http.MethodPost, "/audio/speech", req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sseEvent is one server-sent event
+type sseEvent struct {
+	// name is the event: field, empty when absent
+	name string
+	data []byte
+}
+
+// eventReader reads server-sent events from the streaming endpoints other
+// than chat completions, whose events are typed JSON objects
+type eventReader struct {
+	reader *bufio.Reader
+	body   io.ReadCloser
+}
+
+// newEventReader reads the events of body; closing the reader closes body
+func newEventReader(body io.ReadCloser) *eventReader {
+	return &eventReader{reader: bufio.NewReader(body), body: body}
+}
+
+// next returns the next event with data, or io.EOF at the end of the stream
+// or at a "[DONE]" event. Comments and events without data are skipped.
+func (r *eventReader) next() (sseEvent, error) {
+	var event sseEvent
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err != io.EOF {
+				return sseEvent{}, classifyReadError(err)
+			}
+			// A final event without a blank line is still delivered.
+			line = nil
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if len(event.data) > 0 {
+				if string(event.data) == "[DONE]" {
+					return sseEvent{}, io.EOF
+				}
+				return event, nil
+			}
+			if err == io.EOF {
+				return sseEvent{}, io.EOF
+			}
+			event = sseEvent{}
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event.name = string(value)
+		case "data":
+			if event.data != nil {
+				event.data = append(event.data, '\n')
+			}
+			event.data = append(event.data, value...)
+		}
+	}
+}
+
+// close drains a bounded amount of the rest of the stream and closes it
+func (r *eventReader) close() error {
+	return drainAndClose(r.body)
+}
+
+// streamEventError returns the error of an error event: an *APIError when
+// its data is an error envelope or bare error object
+func streamEventError(data []byte) error {
+	apiErr := &APIError{StatusCode: http.StatusOK}
+	if apiErr.decodeEnvelope(data) {
+		return apiErr
+	}
+	envelope := append(append([]byte(`{"error":`), data...), '}')
+	if apiErr.decodeEnvelope(envelope) {
+		return apiErr
+	}
+	return fmt.Errorf("stream error: %s", data)
+}
@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WithStopEmulation enforces the Stop sequences of chat requests on the client
+// side, for providers and models that ignore the stop parameter. A choice is
+// cut off as soon as a stop sequence appears: the text is trimmed before it,
+// the chunk is marked with finish reason "stop", and later text of the choice
+// is dropped. The request is canceled once every choice has ended.
+// Non-streaming answers are trimmed the same way. Text that may be the start
+// of a stop sequence is held back until it is ruled out.
+func WithStopEmulation() ClientOption {
+	return func(c *Client) {
+		c.stopEmulation = true
+	}
+}
+
+// finishReasonStop marks an answer that ended naturally or at a stop sequence
+const finishReasonStop = "stop"
+
+// cutAtStop returns text before the earliest stop sequence and whether one
+// was found
+func cutAtStop(text string, stops []string) (string, bool) {
+	cut := -1
+	for _, stop := range stops {
+		if stop == "" {
+			continue
+		}
+		if i := strings.Index(text, stop); i >= 0 && (cut < 0 || i < cut) {
+			cut = i
+		}
+	}
+	if cut < 0 {
+		return text, false
+	}
+	return text[:cut], true
+}
+
+// stopWatcher looks for stop sequences across the chunks of a stream
+type stopWatcher struct {
+	stops []string
+	// held is text per choice index that may begin a stop sequence
+	held map[int]string
+	// choices is the number of choices requested; ended holds those that
+	// finished, stopped those that a stop sequence ended
+	choices int
+	ended   map[int]bool
+	stopped map[int]bool
+}
+
+// newStopWatcher returns nil when there is nothing to watch for. choices is
+// the N of the request.
+func newStopWatcher(stops []string, choices int) *stopWatcher {
+	var nonEmpty []string
+	for _, stop := range stops {
+		if stop != "" {
+			nonEmpty = append(nonEmpty, stop)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return nil
+	}
+	return &stopWatcher{
+		stops:   nonEmpty,
+		held:    make(map[int]string),
+		choices: max(choices, 1),
+		ended:   make(map[int]bool),
+		stopped: make(map[int]bool),
+	}
+}
+
+// process rewrites the deltas of chunk and reports whether stop sequences
+// have ended the stream: every choice has finished, at least one at a stop
+// sequence
+func (w *stopWatcher) process(chunk *ChatCompletionStreamResponse) bool {
+	changed := false
+	defer func() {
+		if changed {
+			w.syncRaw(chunk)
+		}
+	}()
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if w.stopped[choice.Index] {
+			// The choice already ended at a stop sequence.
+			changed = changed || choice.Delta.Content != "" || choice.FinishReason != nil
+			choice.Delta.Content = ""
+			choice.FinishReason = nil
+			continue
+		}
+		original := choice.Delta.Content
+		text := w.held[choice.Index] + original
+		delete(w.held, choice.Index)
+
+		if before, ok := cutAtStop(text, w.stops); ok {
+			choice.Delta.Content = before
+			reason := finishReasonStop
+			choice.FinishReason = &reason
+			w.stopped[choice.Index], w.ended[choice.Index] = true, true
+			changed = true
+			continue
+		}
+		if choice.FinishReason != nil {
+			w.ended[choice.Index] = true
+		}
+		if choice.FinishReason == nil {
+			if hold := w.partialStop(text); hold > 0 {
+				w.held[choice.Index] = text[len(text)-hold:]
+				text = text[:len(text)-hold]
+			}
+		}
+		choice.Delta.Content = text
+		changed = changed || text != original
+	}
+	return len(w.stopped) > 0 && len(w.ended) >= w.choices
+}
+
+// syncRaw re-encodes a rewritten chunk so Raw matches its deltas
+func (w *stopWatcher) syncRaw(chunk *ChatCompletionStreamResponse) {
+	if raw, err := json.Marshal(chunk); err == nil {
+		chunk.Raw = raw
+	}
+}
+
+// partialStop returns the length of the longest suffix of text that is a
+// proper prefix of a stop sequence
+func (w *stopWatcher) partialStop(text string) int {
+	longest := 0
+	for _, stop := range w.stops {
+		for n := min(len(stop)-1, len(text)); n > longest; n-- {
+			if strings.HasSuffix(text, stop[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// flush returns a chunk releasing held text once the stream ends, or nil
+func (w *stopWatcher) flush() *ChatCompletionStreamResponse {
+	if len(w.held) == 0 {
+		return nil
+	}
+	indexes := slices.Sorted(maps.Keys(w.held))
+	chunk := &ChatCompletionStreamResponse{}
+	chunk.Choices = slices.Grow(chunk.Choices, len(indexes))[:len(indexes)]
+	for i, index := range indexes {
+		chunk.Choices[i].Index = index
+		chunk.Choices[i].Delta.Content = w.held[index]
+	}
+	clear(w.held)
+	w.syncRaw(chunk)
+	return chunk
+}
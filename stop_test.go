@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+// stopDelta is one choice delta of a test chunk; an empty finish leaves the
+// finish reason unset
+type stopDelta struct {
+	index   int
+	content string
+	finish  string
+}
+
+func TestStopWatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		stops  []string
+		n      int
+		chunks [][]stopDelta
+		// wantText and wantFinish are the text and last finish reason each
+		// choice ends with, flushed text included
+		wantText   map[int]string
+		wantFinish map[int]string
+		wantDone   bool
+		wantFlush  map[int]string
+	}{
+		{
+			name:  "stop split across chunks",
+			stops: []string{"END"},
+			chunks: [][]stopDelta{
+				{{content: "hello E"}},
+				{{content: "ND more"}},
+			},
+			wantText:   map[int]string{0: "hello "},
+			wantFinish: map[int]string{0: "stop"},
+			wantDone:   true,
+		},
+		{
+			name:  "held prefix ruled out by the next chunk",
+			stops: []string{"STOP"},
+			chunks: [][]stopDelta{
+				{{content: "ab ST"}},
+				{{content: "ay"}},
+			},
+			wantText: map[int]string{0: "ab STay"},
+		},
+		{
+			name:  "held prefix released by flush",
+			stops: []string{"STOP"},
+			chunks: [][]stopDelta{
+				{{content: "abc ST"}},
+			},
+			wantText:  map[int]string{0: "abc ST"},
+			wantFlush: map[int]string{0: "ST"},
+		},
+		{
+			name:  "one choice stopped and one finishing naturally",
+			stops: []string{"<END>"},
+			n:     2,
+			chunks: [][]stopDelta{
+				{{index: 0, content: "one <E"}, {index: 1, content: "two"}},
+				{{index: 0, content: "ND> tail"}, {index: 1, content: " three"}},
+				{{index: 0, content: "late", finish: "length"}, {index: 1, finish: "stop"}},
+			},
+			wantText:   map[int]string{0: "one ", 1: "two three"},
+			wantFinish: map[int]string{0: "stop", 1: "stop"},
+			wantDone:   true,
+		},
+		{
+			name:  "choices finishing naturally",
+			stops: []string{"<END>"},
+			n:     2,
+			chunks: [][]stopDelta{
+				{{index: 0, content: "one", finish: "stop"}, {index: 1, content: "two <"}},
+				{{index: 1, content: "3>", finish: "length"}},
+			},
+			wantText:   map[int]string{0: "one", 1: "two <3>"},
+			wantFinish: map[int]string{0: "stop", 1: "length"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newStopWatcher(tt.stops, tt.n)
+			text := make(map[int]string)
+			finish := make(map[int]string)
+			collect := func(chunk *ChatCompletionStreamResponse) {
+				// Raw must follow the rewritten deltas.
+				var raw ChatCompletionStreamResponse
+				if err := json.Unmarshal(chunk.Raw, &raw); err != nil {
+					t.Fatalf("decode Raw: %v", err)
+				}
+				for i, choice := range chunk.Choices {
+					if got := raw.Choices[i].Delta.Content; got != choice.Delta.Content {
+						t.Errorf("Raw content %q, delta %q", got, choice.Delta.Content)
+					}
+					text[choice.Index] += choice.Delta.Content
+					if choice.FinishReason != nil {
+						finish[choice.Index] = *choice.FinishReason
+					}
+				}
+			}
+
+			var done bool
+			for i, deltas := range tt.chunks {
+				if done {
+					t.Fatalf("done before chunk %d", i)
+				}
+				chunk := &ChatCompletionStreamResponse{Choices: make([]StreamChoice, len(deltas))}
+				for j, d := range deltas {
+					chunk.Choices[j] = StreamChoice{Index: d.index, Delta: StreamDelta{Content: d.content}}
+					if d.finish != "" {
+						chunk.Choices[j].FinishReason = &d.finish
+					}
+				}
+				raw, err := json.Marshal(chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				chunk.Raw = raw
+				done = w.process(chunk)
+				collect(chunk)
+			}
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+
+			flushed := make(map[int]string)
+			if chunk := w.flush(); chunk != nil {
+				for _, choice := range chunk.Choices {
+					flushed[choice.Index] = choice.Delta.Content
+				}
+				collect(chunk)
+			}
+			if w.flush() != nil {
+				t.Error("second flush returned text")
+			}
+
+			if !maps.Equal(flushed, tt.wantFlush) {
+				t.Errorf("flushed %q, want %q", flushed, tt.wantFlush)
+			}
+			if !maps.Equal(text, tt.wantText) {
+				t.Errorf("text %q, want %q", text, tt.wantText)
+			}
+			if !maps.Equal(finish, tt.wantFinish) {
+				t.Errorf("finish reasons %q, want %q", finish, tt.wantFinish)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// StoredCompletionListOptions filters the stored chat completions listing
+type StoredCompletionListOptions struct {
+	ListOptions
+	// Model lists only completions created with this model
+	Model string
+	// Metadata lists only completions carrying all of these metadata pairs
+	Metadata map[string]string
+}
+
+// values encodes the options as query parameters
+func (o StoredCompletionListOptions) values() url.Values {
+	q := o.ListOptions.Values()
+	if o.Model != "" {
+		q.Set("model", o.Model)
+	}
+	for key, value := range o.Metadata {
+		q.Set("metadata["+key+"]", value)
+	}
+	return q
+}
+
+// StoredMessage is a message of a stored chat completion
+type StoredMessage struct {
+	ID string `json:"id"`
+	Message
+}
+
+// MarshalJSON encodes the message with its ID, which the promoted
+// Message.MarshalJSON would drop
+func (m StoredMessage) MarshalJSON() ([]byte, error) {
+	message, err := json.Marshal(m.Message)
+	if err != nil {
+		return nil, err
+	}
+	id, err := json.Marshal(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Splice the ID into the message object.
+	out := append([]byte(`{"id":`), id...)
+	if len(message) > 2 {
+		out = append(out, ',')
+	}
+	return append(out, message[1:]...), nil
+}
+
+// UnmarshalJSON decodes the message and its ID
+func (m *StoredMessage) UnmarshalJSON(data []byte) error {
+	var id struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	m.ID = id.ID
+	return json.Unmarshal(data, &m.Message)
+}
+
+// ListStoredCompletions lists the chat completions created with Store set
+func (c *Client) ListStoredCompletions(opts StoredCompletionListOptions) *Pager[ChatCompletionResponse] {
+	return newPager(c, "/chat/completions", opts.values(), func(r ChatCompletionResponse) string {
+		return r.ID
+	})
+}
+
+// GetStoredCompletion retrieves a stored chat completion
+func (c *Client) GetStoredCompletion(ctx context.Context, id string) (*ChatCompletionResponse, error) {
+	var completion ChatCompletionResponse
+	if err := c.Do(ctx, http.MethodGet, "/chat/completions/"+url.PathEscape(id), nil, &completion); err != nil {
+		return nil, err
+	}
+	return &completion, nil
+}
+
+// DeleteStoredCompletion deletes a stored chat completion
+func (c *Client) DeleteStoredCompletion(ctx context.Context, id string) error {
+	return c.Do(ctx, http.MethodDelete, "/chat/completions/"+url.PathEscape(id), nil, nil)
+}
+
+// ListStoredCompletionMessages lists the messages of a stored chat completion
+func (c *Client) ListStoredCompletionMessages(id string, opts ListOptions) *Pager[StoredMessage] {
+	return NewPager(c, "/chat/completions/"+url.PathEscape(id)+"/messages", opts, func(m StoredMessage) string {
+		return m.ID
+	})
+}
@@ -0,0 +1,101 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultStreamTimeout is how long a stream may go without data before it is
+// abandoned, unless WithStreamTimeout says otherwise
+const defaultStreamTimeout = 2 * time.Minute
+
+// errStreamIdle is the cancellation cause of a stream that went idle
+var errStreamIdle = errors.New("stream idle")
+
+// WithStreamTimeout sets how long a streaming call may wait for the response
+// headers or for the next data before it fails. Streams are sent without the
+// HTTP client's overall Timeout, which would cut off long answers from
+// reasoning models, so this idle timeout and the context deadline bound them
+// instead. It defaults to two minutes; zero or less waits indefinitely.
+func WithStreamTimeout(idle time.Duration) ClientOption {
+	return func(c *Client) {
+		c.streamTimeout = idle
+	}
+}
+
+// idleTimer cancels a request once it has gone idle for too long
+type idleTimer struct {
+	idle   time.Duration
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	timer  *time.Timer
+}
+
+// withIdleTimeout returns a context canceled once the timer runs for idle
+// without activity
+func withIdleTimeout(ctx context.Context, idle time.Duration) (context.Context, *idleTimer) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &idleTimer{idle: idle, ctx: ctx, cancel: cancel}
+}
+
+// start runs the timer; it is called just before the request is sent, so
+// waiting for a concurrency slot does not count
+func (t *idleTimer) start() {
+	t.timer = time.AfterFunc(t.idle, func() { t.cancel(errStreamIdle) })
+}
+
+// fired reports whether the request was canceled for being idle
+func (t *idleTimer) fired() bool {
+	return context.Cause(t.ctx) == errStreamIdle
+}
+
+// stop releases the timer and context
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.cancel(nil)
+}
+
+// sendError reports a request that timed out before its headers arrived
+func (t *idleTimer) sendError(err error) error {
+	if t.fired() {
+		return fmt.Errorf("%w: no response headers after %s", ErrResponseHeaderTimeout, t.idle)
+	}
+	return err
+}
+
+// wrap resets the timer on every read of body
+func (t *idleTimer) wrap(body io.ReadCloser) io.ReadCloser {
+	return &idleBody{ReadCloser: body, timer: t}
+}
+
+// idleBody is a response body guarded by an idleTimer
+type idleBody struct {
+	io.ReadCloser
+	timer *idleTimer
+	once  sync.Once
+}
+
+// Read implements io.Reader
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.timer.fired() {
+		return n, fmt.Errorf("%w: no data for %s", ErrStreamReadTimeout, b.timer.idle)
+	}
+	if n > 0 {
+		b.timer.timer.Reset(b.timer.idle)
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *idleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.timer.stop)
+	return err
+}
@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownFields is returned in strict decoding mode when a response
+// contains fields the typed structs do not model.
+var ErrUnknownFields = errors.New("response contains unknown fields")
+
+// WithStrictDecoding rejects responses containing fields the typed structs do
+// not model and decodes untyped numbers as json.Number instead of float64.
+// It is meant for CI runs that should notice schema drift; by default new
+// fields are ignored.
+func WithStrictDecoding() ClientOption {
+	return func(c *Client) {
+		c.strict = true
+	}
+}
+
+// WithStrictStreamParsing makes StreamReader.Recv fail with a
+// *StreamParseError on a malformed or truncated frame. By default such frames
+// are skipped, counted by MalformedFrames, and reading resumes on the next
+// data line.
+func WithStrictStreamParsing() ClientOption {
+	return func(c *Client) {
+		c.strictStreams = true
+	}
+}
+
+// decodeResponse decodes the JSON document in r into v, honoring the client's
+// strict decoding mode
+func (c *Client) decodeResponse(r io.Reader, v any) error {
+	if !c.strict {
+		return json.NewDecoder(r).Decode(v)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v, true)
+}
+
+// decodeJSON decodes data into v. In strict mode numbers in untyped values are
+// kept as json.Number and keys without a matching field are reported as
+// ErrUnknownFields, including inside types with custom unmarshalers.
+func decodeJSON(data []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	var tree any
+	dec = json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	var unknown []string
+	findUnknownFields(tree, reflect.TypeOf(v), "", &unknown)
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("%w: %s", ErrUnknownFields, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// findUnknownFields walks the decoded JSON tree alongside t and appends the
+// path of every object key that no struct field accepts. Values whose shape
+// does not match t are skipped; the decoder already reported real mismatches
+// and custom unmarshalers accept several shapes.
+func findUnknownFields(tree any, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := tree.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for key, value := range object {
+			field, ok := fields[key]
+			if !ok {
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+			if !ok {
+				*unknown = append(*unknown, joinPath(path, key))
+				continue
+			}
+			findUnknownFields(value, field, joinPath(path, key), unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := tree.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			findUnknownFields(item, t.Elem(), path+"["+strconv.Itoa(i)+"]", unknown)
+		}
+	case reflect.Map:
+		object, ok := tree.(map[string]any)
+		if !ok {
+			return
+		}
+		for key, value := range object {
+			findUnknownFields(value, t.Elem(), joinPath(path, key), unknown)
+		}
+	}
+}
+
+// joinPath appends an object key to a field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonFields maps the JSON names of t's fields, including those promoted from
+// embedded structs, to their types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for n, ft := range jsonFields(embedded) {
+					if _, ok := fields[n]; !ok {
+						fields[n] = ft
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Thread is a conversation that assistants run on
+type Thread struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	CreatedAt     int64             `json:"created_at"`
+	ToolResources *ToolResources    `json:"tool_resources,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// ThreadRequest creates or modifies a thread. Messages only apply when
+// creating it.
+type ThreadRequest struct {
+	Messages      []ThreadMessageRequest `json:"messages,omitempty"`
+	ToolResources *ToolResources         `json:"tool_resources,omitempty"`
+	Metadata      map[string]string      `json:"metadata,omitempty"`
+}
+
+// ThreadMessageRequest adds a message to a thread
+type ThreadMessageRequest struct {
+	// Role is RoleUser or RoleAssistant
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Attachments give the files of the message to the listed tools
+	Attachments []MessageAttachment `json:"attachments,omitempty"`
+	Metadata    map[string]string   `json:"metadata,omitempty"`
+}
+
+// MessageAttachment makes a file available to tools of a run
+type MessageAttachment struct {
+	FileID string `json:"file_id"`
+	// Tools are such as CodeInterpreterTool() or FileSearchTool()
+	Tools []Tool `json:"tools"`
+}
+
+// ThreadMessage is a message of a thread
+type ThreadMessage struct {
+	ID          string                 `json:"id"`
+	Object      string                 `json:"object"`
+	CreatedAt   int64                  `json:"created_at"`
+	ThreadID    string                 `json:"thread_id"`
+	Status      string                 `json:"status,omitempty"`
+	Role        string                 `json:"role"`
+	Content     []ThreadMessageContent `json:"content"`
+	AssistantID string                 `json:"assistant_id,omitempty"`
+	RunID       string                 `json:"run_id,omitempty"`
+	Attachments []MessageAttachment    `json:"attachments,omitempty"`
+	Metadata    map[string]string      `json:"metadata,omitempty"`
+}
+
+// Text concatenates the text parts of the message
+func (m *ThreadMessage) Text() string {
+	return threadMessageText(m.Content)
+}
+
+// ThreadMessageContent is a part of a thread message: text, an image, or a
+// refusal, as given by Type
+type ThreadMessageContent struct {
+	Type      string           `json:"type"`
+	Text      *ThreadText      `json:"text,omitempty"`
+	ImageFile *ThreadImageFile `json:"image_file,omitempty"`
+	ImageURL  *ImageURL        `json:"image_url,omitempty"`
+	Refusal   string           `json:"refusal,omitempty"`
+}
+
+// ThreadText is the text of a message part
+type ThreadText struct {
+	Value string `json:"value"`
+	// Annotations are the file citations and paths in Value, undecoded
+	Annotations []json.RawMessage `json:"annotations,omitempty"`
+}
+
+// ThreadImageFile is an uploaded image within a message
+type ThreadImageFile struct {
+	FileID string `json:"file_id"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// threadMessageText concatenates the text of parts
+func threadMessageText(parts []ThreadMessageContent) string {
+	var b strings.Builder
+	for _, part := range parts {
+		if part.Text != nil {
+			b.WriteString(part.Text.Value)
+		}
+	}
+	return b.String()
+}
+
+// CreateThread creates a thread, optionally with its first messages
+func (c *Client) CreateThread(ctx context.Context, req ThreadRequest) (*Thread, error) {
+	var thread Thread
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, "/threads", req, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// GetThread retrieves a thread
+func (c *Client) GetThread(ctx context.Context, id string) (*Thread, error) {
+	var thread Thread
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodGet, "/threads/"+url.PathEscape(id), nil, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// ModifyThread updates the tool resources and metadata of a thread
+func (c *Client) ModifyThread(ctx context.Context, id string, req ThreadRequest) (*Thread, error) {
+	var thread Thread
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, "/threads/"+url.PathEscape(id), req, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// DeleteThread deletes a thread
+func (c *Client) DeleteThread(ctx context.Context, id string) error {
+	return c.Do(withBeta(ctx, assistantsBeta), http.MethodDelete, "/threads/"+url.PathEscape(id), nil, nil)
+}
+
+// CreateMessage adds a message to a thread
+func (c *Client) CreateMessage(ctx context.Context, threadID string, req ThreadMessageRequest) (*ThreadMessage, error) {
+	var message ThreadMessage
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodPost, threadPath(threadID, "messages"), req, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetMessage retrieves a message of a thread
+func (c *Client) GetMessage(ctx context.Context, threadID, messageID string) (*ThreadMessage, error) {
+	var message ThreadMessage
+	if err := c.Do(withBeta(ctx, assistantsBeta), http.MethodGet, threadPath(threadID, "messages", messageID), nil, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// DeleteMessage deletes a message of a thread
+func (c *Client) DeleteMessage(ctx context.Context, threadID, messageID string) error {
+	return c.Do(withBeta(ctx, assistantsBeta), http.MethodDelete, threadPath(threadID, "messages", messageID), nil, nil)
+}
+
+// ListMessages lists the messages of a thread, newest first unless
+// opts.Order is SortAsc
+func (c *Client) ListMessages(threadID string, opts ListOptions) *Pager[ThreadMessage] {
+	p := NewPager(c, threadPath(threadID, "messages"), opts, func(m ThreadMessage) string { return m.ID })
+	p.beta = assistantsBeta
+	return p
+}
+
+// threadPath joins the escaped thread ID and the segments below it
+func threadPath(threadID string, segments ...string) string {
+	path := "/threads/" + url.PathEscape(threadID)
+	for i, segment := range segments {
+		// Odd segments are IDs, even ones resource names.
+		if i%2 == 1 {
+			segment = url.PathEscape(segment)
+		}
+		path += "/" + segment
+	}
+	return path
+}
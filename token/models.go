@@ -0,0 +1,34 @@
+package token
+
+import "strings"
+
+// DefaultContextWindow is assumed for models missing from the table.
+const DefaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window size.
+var contextWindows = map[string]int{
+	"gpt-5":         400000,
+	"gpt-4.1":       1047576,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4-32k":     32768,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1-mini":       128000,
+	"o1":            200000,
+	"o3":            200000,
+	"o4-mini":       200000,
+}
+
+// ContextWindow returns the context window of model in tokens, matching the
+// longest known prefix so dated snapshots such as "gpt-4o-2024-08-06" resolve
+// to their family. Unknown models report DefaultContextWindow.
+func ContextWindow(model string) int {
+	best, size := "", DefaultContextWindow
+	for prefix, window := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, size = prefix, window
+		}
+	}
+	return size
+}
@@ -0,0 +1,97 @@
+// Package token provides lightweight token estimation for chat prompts.
+//
+// Counts are heuristic approximations of BPE tokenizers: roughly four ASCII
+// characters per token and one token per non-ASCII rune. They are intended for
+// budgeting and truncation decisions, not for exact billing.
+package token
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// charsPerToken is the average number of ASCII characters covered by a token.
+const charsPerToken = 4
+
+// counter accumulates an estimate rune by rune.
+type counter struct {
+	ascii int
+	other int
+}
+
+// add accounts for a single rune.
+func (c *counter) add(r rune) {
+	if r < utf8.RuneSelf || unicode.IsSpace(r) {
+		c.ascii++
+		return
+	}
+	c.other++
+}
+
+// tokens returns the current estimate.
+func (c counter) tokens() int {
+	return c.other + (c.ascii+charsPerToken-1)/charsPerToken
+}
+
+// Count estimates the number of tokens in text.
+func Count(text string) int {
+	var c counter
+	for _, r := range text {
+		c.add(r)
+	}
+	return c.tokens()
+}
+
+// Counter keeps a running estimate of text added piece by piece, such as
+// streamed deltas, without recounting what came before. Its total matches
+// Count of the concatenated text.
+type Counter struct {
+	c counter
+}
+
+// Add accounts for text.
+func (c *Counter) Add(text string) {
+	for _, r := range text {
+		c.c.add(r)
+	}
+}
+
+// Tokens returns the estimate of everything added so far.
+func (c Counter) Tokens() int {
+	return c.c.tokens()
+}
+
+// Truncate shortens text so that its estimated token count does not exceed
+// max, preferring to cut at a word boundary and marking the cut with an
+// ellipsis. Text that already fits is returned unchanged.
+func Truncate(text string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if Count(text) <= max {
+		return text
+	}
+
+	const ellipsis = "…"
+	budget := max - Count(ellipsis)
+	if budget <= 0 {
+		return ellipsis
+	}
+
+	var c counter
+	cut := 0
+	for i, r := range text {
+		c.add(r)
+		if c.tokens() > budget {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+
+	head := text[:cut]
+	if idx := strings.LastIndexFunc(head, unicode.IsSpace); idx > len(head)/2 {
+		head = head[:idx]
+	}
+	return strings.TrimRightFunc(head, unicode.IsSpace) + ellipsis
+}
@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxToolIterations bounds the model round trips of a ToolRunner
+const defaultMaxToolIterations = 10
+
+// ErrToolIterations is returned by ToolRunner.Run when the model still asks
+// for tools after MaxIterations round trips
+var ErrToolIterations = errors.New("too many tool call iterations")
+
+// ToolHandler executes a tool call. arguments is the JSON the model produced;
+// the returned string is sent back to the model as the tool's result.
+type ToolHandler func(ctx context.Context, arguments string) (string, error)
+
+// ToolRunner runs the chat loop of an agent: it sends the conversation, runs
+// the handlers of the tools the model calls, appends their results, and asks
+// again until the model answers without calling a tool.
+type ToolRunner struct {
+	// MaxIterations bounds the round trips to the model; it defaults to 10
+	MaxIterations int
+	// Timeout bounds a whole Run when positive
+	Timeout time.Duration
+
+	client   *Client
+	tools    []Tool
+	handlers map[string]ToolHandler
+}
+
+// NewToolRunner returns a runner sending requests with c
+func NewToolRunner(c *Client) *ToolRunner {
+	return &ToolRunner{client: c, handlers: make(map[string]ToolHandler)}
+}
+
+// Register offers the function def to the model and runs handler for its
+// calls. Registering a name again replaces its handler.
+func (r *ToolRunner) Register(def FunctionDefinition, handler ToolHandler) {
+	if _, ok := r.handlers[def.Name]; !ok {
+		r.tools = append(r.tools, Tool{Type: ToolTypeFunction, Function: def})
+	}
+	r.handlers[def.Name] = handler
+}
+
+// Run completes req, executing tool calls until the model gives a final
+// answer, and returns the answer and the conversation including every tool
+// call and result. The registered tools are added to req.Tools. Calls of one
+// response run concurrently, unless req.ParallelToolCalls is false. A handler
+// error or an unknown tool is reported to the model as the result of its
+// call, so it can recover.
+func (r *ToolRunner) Run(ctx context.Context, req ChatCompletionRequest) (string, []Message, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	maxIterations := r.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultMaxToolIterations
+	}
+
+	req.Tools = append(append([]Tool(nil), req.Tools...), r.tools...)
+	req.Messages = append([]Message(nil), req.Messages...)
+	for range maxIterations {
+		payload, err := r.client.createChatCompletion(ctx, req)
+		if err != nil {
+			return "", req.Messages, err
+		}
+		if len(payload.Choices) == 0 {
+			return "", req.Messages, ErrNoChoices
+		}
+
+		message := payload.Choices[0].Message
+		req.Messages = append(req.Messages, message)
+		if message.Refusal != "" {
+			return "", req.Messages, &RefusalError{Refusal: message.Refusal}
+		}
+		if len(message.ToolCalls) == 0 {
+			answer := strings.TrimSpace(message.Content)
+			if answer == "" {
+				return "", req.Messages, ErrEmptyContent
+			}
+			return r.client.postProcess.apply(req, answer), req.Messages, nil
+		}
+		sequential := req.ParallelToolCalls != nil && !*req.ParallelToolCalls
+		req.Messages = append(req.Messages, r.execute(ctx, message.ToolCalls, sequential)...)
+	}
+	return "", req.Messages, fmt.Errorf("%w: %d", ErrToolIterations, maxIterations)
+}
+
+// execute runs calls, concurrently unless sequential, and returns their tool
+// messages in order
+func (r *ToolRunner) execute(ctx context.Context, calls []ToolCall, sequential bool) []Message {
+	results := make([]Message, len(calls))
+	if sequential {
+		for i, call := range calls {
+			results[i] = Message{Role: RoleTool, ToolCallID: call.ID, Content: r.call(ctx, call)}
+		}
+		return results
+	}
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Go(func() {
+			results[i] = Message{Role: RoleTool, ToolCallID: call.ID, Content: r.call(ctx, call)}
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// call runs the handler of one call and returns its result or error text
+func (r *ToolRunner) call(ctx context.Context, call ToolCall) string {
+	handler, ok := r.handlers[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", call.Function.Name)
+	}
+	result, err := handler(ctx, call.Function.Arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package openai
+
+import "encoding/json"
+
+// Tool types. Code interpreter and file search are built-in tools of
+// assistants.
+const (
+	ToolTypeFunction        = "function"
+	ToolTypeCodeInterpreter = "code_interpreter"
+	ToolTypeFileSearch      = "file_search"
+)
+
+// Tool is a tool the model may call
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// MarshalJSON omits Function for built-in tools
+func (t Tool) MarshalJSON() ([]byte, error) {
+	if t.Type != ToolTypeFunction {
+		return json.Marshal(struct {
+			Type string `json:"type"`
+		}{t.Type})
+	}
+	type alias Tool
+	return json.Marshal(alias(t))
+}
+
+// FunctionDefinition describes a function the model may call
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the arguments, such as a
+	// json.RawMessage or a map
+	Parameters any `json:"parameters,omitempty"`
+	// Strict makes the model follow Parameters exactly
+	Strict bool `json:"strict,omitempty"`
+}
+
+// FunctionTool builds a function tool
+func FunctionTool(name, description string, parameters any) Tool {
+	return Tool{
+		Type:     ToolTypeFunction,
+		Function: FunctionDefinition{Name: name, Description: description, Parameters: parameters},
+	}
+}
+
+// ToolCall is a call of a tool requested by the model
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names the function to call and its JSON-encoded arguments
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a fragment of a tool call in a stream chunk. The first
+// fragment of a call carries its ID, type, and name; later ones append to
+// Function.Arguments. Index identifies the call within the choice.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// ToolCallAccumulator reassembles the tool calls of one choice of a stream
+// from their fragments. The zero value accumulates choice 0.
+type ToolCallAccumulator struct {
+	// Choice is the index of the choice whose calls are accumulated
+	Choice int
+
+	calls    []ToolCall
+	position map[int]int
+	// done is the number of calls already returned as complete by Add
+	done int
+}
+
+// Add merges the tool call fragments of chunk and returns the calls it
+// completed. A call is complete, its arguments final, once a call with
+// another index starts or the choice finishes.
+func (a *ToolCallAccumulator) Add(chunk ChatCompletionStreamResponse) []ToolCall {
+	finished := false
+	for _, choice := range chunk.Choices {
+		if choice.Index != a.Choice {
+			continue
+		}
+		for _, delta := range choice.Delta.ToolCalls {
+			a.merge(delta)
+		}
+		finished = finished || choice.FinishReason != nil
+	}
+
+	pending := len(a.calls)
+	if !finished && pending > 0 {
+		// The last call started may still receive arguments.
+		pending--
+	}
+	if pending <= a.done {
+		return nil
+	}
+	completed := append([]ToolCall(nil), a.calls[a.done:pending]...)
+	a.done = pending
+	return completed
+}
+
+// merge appends a fragment to the call with its index
+func (a *ToolCallAccumulator) merge(delta ToolCallDelta) {
+	if a.position == nil {
+		a.position = make(map[int]int)
+	}
+	i, ok := a.position[delta.Index]
+	if !ok {
+		i = len(a.calls)
+		a.position[delta.Index] = i
+		a.calls = append(a.calls, ToolCall{Type: ToolTypeFunction})
+	}
+	call := &a.calls[i]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	call.Function.Name += delta.Function.Name
+	call.Function.Arguments += delta.Function.Arguments
+}
+
+// ToolCalls returns every call assembled so far, including one still
+// receiving arguments
+func (a *ToolCallAccumulator) ToolCalls() []ToolCall {
+	return append([]ToolCall(nil), a.calls...)
+}
@@ -0,0 +1,101 @@
+package openai
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes connection pooling and dialing of the client's
+// *http.Transport. Zero fields keep the transport's own values, which are
+// those of http.DefaultTransport unless WithHTTPClient supplies another one.
+type TransportConfig struct {
+	// MaxIdleConns bounds idle connections across all hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds idle connections kept for each host; raise it
+	// for high-QPS use, since the net/http default of 2 forces reconnects
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost bounds dialing, active, and idle connections per host
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer
+	IdleConnTimeout time.Duration
+	// DialTimeout bounds establishing a TCP connection; failures match
+	// ErrConnectTimeout
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period of new connections. Setting it or
+	// DialTimeout replaces the transport's DialContext with a net.Dialer.
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake; failures match
+	// ErrTLSHandshake
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for response headers after the
+	// request is written; failures match ErrResponseHeaderTimeout
+	ResponseHeaderTimeout time.Duration
+	// ForceHTTP2 attempts HTTP/2 even when the transport has a custom dialer
+	// or TLS config
+	ForceHTTP2 bool
+	// DisableHTTP2 restricts connections to HTTP/1.1
+	DisableHTTP2 bool
+}
+
+// WithTransportConfig tunes the client's transport without building an
+// http.Client by hand. It applies to the default transport and to an
+// *http.Transport given to WithHTTPClient, which is cloned rather than
+// modified; other round trippers are used as they are. Limits apply to every
+// host separately, since each host gets its own clone.
+func WithTransportConfig(cfg TransportConfig) ClientOption {
+	return func(c *Client) {
+		c.transport = &cfg
+	}
+}
+
+// apply returns a clone of base with cfg applied, or base itself when it is
+// not an *http.Transport
+func (cfg *TransportConfig) apply(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return base
+	}
+	t := transport.Clone()
+
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	if cfg.DialTimeout > 0 || cfg.KeepAlive > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if cfg.DialTimeout > 0 {
+			dialer.Timeout = cfg.DialTimeout
+		}
+		if cfg.KeepAlive > 0 {
+			dialer.KeepAlive = cfg.KeepAlive
+		}
+		t.DialContext = dialer.DialContext
+	}
+	if cfg.ForceHTTP2 {
+		t.ForceAttemptHTTP2 = true
+	}
+	if cfg.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	}
+	return t
+}
@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	// ErrConnectTimeout means the TCP connection could not be established in time
+	ErrConnectTimeout = errors.New("connect timeout")
+	// ErrTLSHandshake means the TLS handshake failed or timed out
+	ErrTLSHandshake = errors.New("TLS handshake failed")
+	// ErrResponseHeaderTimeout means the server accepted the request but did
+	// not send response headers in time
+	ErrResponseHeaderTimeout = errors.New("response header timeout")
+	// ErrStreamReadTimeout means a streaming response stalled mid-body
+	ErrStreamReadTimeout = errors.New("stream read timeout")
+)
+
+// classifySendError wraps errors from sending a request with the matching
+// timeout class so retry policies and messages can tell them apart. Errors
+// that match no class are returned unchanged.
+func classifySendError(err error) error {
+	var (
+		opErr      *net.OpError
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		verifyErr  *tls.CertificateVerificationError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+
+	msg := err.Error()
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		return fmt.Errorf("%w: %w", ErrConnectTimeout, err)
+	case strings.Contains(msg, "TLS handshake timeout"),
+		errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &verifyErr),
+		errors.As(err, &unknownCA),
+		errors.As(err, &hostErr),
+		errors.As(err, &invalidErr):
+		return fmt.Errorf("%w: %w", ErrTLSHandshake, err)
+	case strings.Contains(msg, "timeout awaiting response headers"),
+		strings.Contains(msg, "Client.Timeout exceeded while awaiting headers"):
+		return fmt.Errorf("%w: %w", ErrResponseHeaderTimeout, err)
+	}
+	return err
+}
+
+// classifyReadError wraps body read errors caused by timeouts with
+// ErrStreamReadTimeout
+func classifyReadError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrStreamReadTimeout, err)
+	}
+	if strings.Contains(err.Error(), "Client.Timeout or context cancellation while reading body") {
+		return fmt.Errorf("%w: %w", ErrStreamReadTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,279 @@
+package openai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Defaults of UploadOptions
+const (
+	// MaxUploadPartSize is the largest part the uploads endpoint accepts
+	MaxUploadPartSize = 64 << 20
+
+	defaultUploadPartSize    = 32 << 20
+	defaultUploadConcurrency = 4
+	defaultUploadPartRetries = 3
+)
+
+// UploadRequest starts an upload of a large file
+type UploadRequest struct {
+	Filename string `json:"filename"`
+	// Purpose is one of the FilePurpose constants
+	Purpose string `json:"purpose"`
+	// Bytes is the size of the whole file, which the parts must add up to
+	Bytes int64 `json:"bytes"`
+	// MimeType is such as "text/jsonl" or "application/pdf"
+	MimeType string `json:"mime_type"`
+}
+
+// Upload is a file being uploaded in parts
+type Upload struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	// Status is "pending", "completed", "cancelled", or "expired"
+	Status string `json:"status"`
+	// File is the resulting file once the upload completed
+	File *File `json:"file,omitempty"`
+}
+
+// UploadPart is a part added to an upload
+type UploadPart struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	UploadID  string `json:"upload_id"`
+}
+
+// UploadOptions configures UploadLargeFile. Zero fields take their defaults.
+type UploadOptions struct {
+	// PartSize is the size of each part but the last; it defaults to 32 MB
+	// and is capped at MaxUploadPartSize. Up to Concurrency parts are held in
+	// memory at once.
+	PartSize int
+	// Concurrency bounds the parts uploaded at once; it defaults to 4
+	Concurrency int
+	// PartRetries bounds how often a failed part is sent again; it defaults
+	// to 3, and a negative value disables retries
+	PartRetries int
+}
+
+// validate reports options out of range
+func (o UploadOptions) validate() error {
+	if o.PartSize < 0 {
+		return fmt.Errorf("invalid upload part size %d", o.PartSize)
+	}
+	if o.Concurrency < 0 {
+		return fmt.Errorf("invalid upload concurrency %d", o.Concurrency)
+	}
+	return nil
+}
+
+// CreateUpload starts an upload, to which parts are added within an hour
+func (c *Client) CreateUpload(ctx context.Context, req UploadRequest) (*Upload, error) {
+	var upload Upload
+	if err := c.Do(ctx, http.MethodPost, "/uploads", req, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// AddUploadPart adds a part of at most MaxUploadPartSize bytes to an upload.
+// Parts may be added concurrently; their order is set by CompleteUpload.
+func (c *Client) AddUploadPart(ctx context.Context, uploadID string, data io.Reader) (*UploadPart, error) {
+	form := newFormBuilder()
+	form.file("data", "part", data)
+	var part UploadPart
+	if err := c.postForm(ctx, "/uploads/"+url.PathEscape(uploadID)+"/parts", form, &part); err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+// completeUploadRequest is the body of a complete request
+type completeUploadRequest struct {
+	PartIDs []string `json:"part_ids"`
+	MD5     string   `json:"md5,omitempty"`
+}
+
+// CompleteUpload assembles the parts, in the order of partIDs, into a file,
+// returned in Upload.File. md5, when not empty, is the hex MD5 checksum the
+// file is checked against.
+func (c *Client) CompleteUpload(ctx context.Context, uploadID string, partIDs []string, md5 string) (*Upload, error) {
+	var upload Upload
+	body := completeUploadRequest{PartIDs: partIDs, MD5: md5}
+	if err := c.Do(ctx, http.MethodPost, "/uploads/"+url.PathEscape(uploadID)+"/complete", body, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// CancelUpload cancels an upload; no parts can be added afterwards
+func (c *Client) CancelUpload(ctx context.Context, uploadID string) (*Upload, error) {
+	var upload Upload
+	if err := c.Do(ctx, http.MethodPost, "/uploads/"+url.PathEscape(uploadID)+"/cancel", nil, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// UploadLargeFile uploads the req.Bytes bytes of r as a file through the
+// uploads endpoints, for files beyond the 512 MB limit of UploadFile. r is
+// read sequentially into parts of opts.PartSize, which are sent concurrently.
+// A failed part is sent again from memory, so a transient failure does not
+// restart the file. The parts are completed with the MD5 checksum of the
+// data. When a part keeps failing, or r does not hold req.Bytes bytes, the
+// upload is cancelled.
+func (c *Client) UploadLargeFile(ctx context.Context, req UploadRequest, r io.Reader, opts UploadOptions) (*File, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	partSize := min(cmp.Or(opts.PartSize, defaultUploadPartSize), MaxUploadPartSize)
+	concurrency := cmp.Or(opts.Concurrency, defaultUploadConcurrency)
+	retries := max(cmp.Or(opts.PartRetries, defaultUploadPartRetries), 0)
+
+	upload, err := c.CreateUpload(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	partIDs, sum, err := c.uploadParts(ctx, upload.ID, req.Bytes, r, partSize, concurrency, retries)
+	if err != nil {
+		// Cancel even when ctx ended, so the parts are not kept until expiry.
+		if _, cancelErr := c.CancelUpload(context.WithoutCancel(ctx), upload.ID); cancelErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to cancel upload: %w", cancelErr))
+		}
+		return nil, err
+	}
+
+	upload, err = c.CompleteUpload(ctx, upload.ID, partIDs, sum)
+	if err != nil {
+		return nil, err
+	}
+	if upload.File == nil {
+		return nil, fmt.Errorf("upload %s completed without a file", upload.ID)
+	}
+	return upload.File, nil
+}
+
+// uploadParts reads r into parts and adds them to the upload, returning the
+// part IDs in order and the hex MD5 checksum of the data
+func (c *Client) uploadParts(
+	ctx context.Context,
+	uploadID string,
+	size int64,
+	r io.Reader,
+	partSize, concurrency, retries int,
+) ([]string, string, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu      sync.Mutex
+		partIDs []string
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		hash    = md5.New()
+		read    int64
+	)
+	for index := 0; ; index++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		data := make([]byte, partSize)
+		n, err := io.ReadFull(r, data)
+		if n == 0 {
+			<-sem
+			if err != nil && err != io.EOF {
+				cancel(fmt.Errorf("failed to read upload: %w", err))
+			}
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			<-sem
+			cancel(fmt.Errorf("failed to read upload: %w", err))
+			break
+		}
+		data = data[:n]
+		hash.Write(data)
+		read += int64(n)
+
+		mu.Lock()
+		partIDs = append(partIDs, "")
+		mu.Unlock()
+		wg.Go(func() {
+			defer func() { <-sem }()
+			part, err := c.addPartWithRetry(ctx, uploadID, index, data, retries)
+			if err != nil {
+				cancel(fmt.Errorf("part %d: %w", index, err))
+				return
+			}
+			mu.Lock()
+			partIDs[index] = part.ID
+			mu.Unlock()
+		})
+		if n < partSize {
+			break
+		}
+	}
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, "", err
+	}
+	if read != size {
+		return nil, "", fmt.Errorf("upload read %d bytes, want %d", read, size)
+	}
+	return partIDs, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// addPartWithRetry adds data as the part at index, sending it again after
+// retryable failures. Every attempt sends the idempotency key of the part.
+func (c *Client) addPartWithRetry(ctx context.Context, uploadID string, index int, data []byte, retries int) (*UploadPart, error) {
+	var backoff RetryTransport
+	scope := fmt.Sprintf("part-%d", index)
+	for attempt := 0; ; attempt++ {
+		part, err := c.AddUploadPart(withIdempotencyScope(ctx, scope), uploadID, bytes.NewReader(data))
+		if err == nil || attempt >= retries || !retryablePartError(ctx, err) {
+			return part, err
+		}
+		if err := sleepContext(ctx, backoff.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryablePartError reports whether adding a part may succeed when sent
+// again: after transport errors and server errors, but not after request
+// errors or the end of ctx
+func retryablePartError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return apiErr.StatusCode >= 500
+}
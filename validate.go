@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// correctionPrompt asks the model to fix an answer that failed validation
+const correctionPrompt = "Your previous response failed validation: %v\nRespond again with a corrected response only."
+
+// ValidationAttempt is one answer of CreateValidatedCompletion and the error
+// its validation returned, nil for the accepted answer
+type ValidationAttempt struct {
+	Content string
+	Err     error
+}
+
+// ValidationError reports an output that still failed validation after all
+// retries
+type ValidationError struct {
+	Attempts []ValidationAttempt
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("output failed validation after %d attempts: %v", len(e.Attempts), e.Unwrap())
+}
+
+// Unwrap returns the validation error of the last attempt
+func (e *ValidationError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// CreateValidatedCompletion runs CreateChatCompletion and checks the answer
+// with validate. Rejected answers are retried up to maxRetries times with the
+// answer and a corrective message describing the error appended to the
+// conversation. Every attempt is returned for inspection; when none passes
+// the error is a *ValidationError.
+func (c *Client) CreateValidatedCompletion(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	maxRetries int,
+	validate func(content string) error,
+) (string, []ValidationAttempt, error) {
+	var attempts []ValidationAttempt
+	for {
+		content, err := c.CreateChatCompletion(ctx, req)
+		if err != nil && !errors.Is(err, ErrEmptyContent) {
+			return "", attempts, err
+		}
+		if err == nil {
+			err = validate(content)
+		}
+		attempts = append(attempts, ValidationAttempt{Content: content, Err: err})
+		if err == nil {
+			return content, attempts, nil
+		}
+		if len(attempts) > maxRetries {
+			return "", attempts, &ValidationError{Attempts: attempts}
+		}
+		// Feed the rejected answer back with what was wrong with it
+		req = appendTurn(req, content, fmt.Sprintf(correctionPrompt, err))
+	}
+}
+
+// ValidateJSON returns a validator for CreateValidatedCompletion that decodes
+// the answer into a T, rejecting unknown fields and trailing data, and then
+// runs check when it is non-nil
+func ValidateJSON[T any](check func(*T) error) func(string) error {
+	return func(content string) error {
+		var v T
+		dec := json.NewDecoder(bytes.NewReader([]byte(content)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return errors.New("invalid JSON: unexpected data after the top-level value")
+		}
+		if check != nil {
+			return check(&v)
+		}
+		return nil
+	}
+}